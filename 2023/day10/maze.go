@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+type p struct{ r, c int }
+
+const (
+	N = iota
+	S
+	W
+	E
+)
+
+var (
+	dirs = []p{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+	opp  = []int{S, N, E, W}
+)
+
+func in(r, c, h, w int) bool {
+	return r >= 0 && r < h && c >= 0 && c < w
+}
+
+func connDirs(ch rune) []int {
+	switch ch {
+	case '|':
+		return []int{N, S}
+	case '-':
+		return []int{W, E}
+	case 'L':
+		return []int{N, E}
+	case 'J':
+		return []int{N, W}
+	case '7':
+		return []int{S, W}
+	case 'F':
+		return []int{S, E}
+	default:
+		return nil
+	}
+}
+
+func connectsBack(ch rune, want int) bool {
+	return slices.Contains(connDirs(ch), want)
+}
+
+func resolveS(sConn [4]bool) rune {
+	switch {
+	case sConn[N] && sConn[S]:
+		return '|'
+	case sConn[W] && sConn[E]:
+		return '-'
+	case sConn[N] && sConn[E]:
+		return 'L'
+	case sConn[N] && sConn[W]:
+		return 'J'
+	case sConn[S] && sConn[W]:
+		return '7'
+	case sConn[S] && sConn[E]:
+		return 'F'
+	default:
+		return '.'
+	}
+}
+
+// Maze is a pipe grid padded to a rectangle, with S replaced by the pipe
+// its neighbours imply.
+type Maze struct {
+	grid  [][]rune
+	h, w  int
+	start p
+	hasS  bool
+}
+
+func ParseMaze(lines []string) *Maze {
+	m := &Maze{h: len(lines), w: maxLen(lines)}
+	m.grid = make([][]rune, m.h)
+	for i, s := range lines {
+		row := make([]rune, m.w)
+		rs := []rune(s)
+		for j := range row {
+			row[j] = '.'
+			if j < len(rs) {
+				row[j] = rs[j]
+			}
+			if row[j] == 'S' {
+				m.start, m.hasS = p{i, j}, true
+			}
+		}
+		m.grid[i] = row
+	}
+	if m.hasS {
+		sConn := [4]bool{}
+		for d := range dirs {
+			nr, nc := m.start.r+dirs[d].r, m.start.c+dirs[d].c
+			if in(nr, nc, m.h, m.w) && connectsBack(m.grid[nr][nc], opp[d]) {
+				sConn[d] = true
+			}
+		}
+		m.grid[m.start.r][m.start.c] = resolveS(sConn)
+	}
+	return m
+}
+
+func (m *Maze) at(x p) rune {
+	if !in(x.r, x.c, m.h, m.w) {
+		return '.'
+	}
+	return m.grid[x.r][x.c]
+}
+
+// Loop is a closed pipe loop as a polygon: Tiles lists every tile in the
+// order the loop visits them, starting from Tiles[0].
+type Loop struct {
+	Tiles []p
+	on    map[p]bool
+}
+
+// LoopFrom follows the pipes from start until they lead back to it. It
+// fails if the pipes leave the grid or run into a tile that does not
+// connect back.
+func (m *Maze) LoopFrom(start p) (*Loop, error) {
+	conns := connDirs(m.at(start))
+	if conns == nil {
+		return nil, fmt.Errorf("no pipe at %d,%d", start.r, start.c)
+	}
+	l := &Loop{Tiles: []p{start}, on: map[p]bool{start: true}}
+	cur, d := start, conns[0]
+	for {
+		next := p{cur.r + dirs[d].r, cur.c + dirs[d].c}
+		if !connectsBack(m.at(next), opp[d]) {
+			return nil, fmt.Errorf("pipe at %d,%d leads to %d,%d, which does not connect back", cur.r, cur.c, next.r, next.c)
+		}
+		if next == start {
+			return l, nil
+		}
+		l.Tiles = append(l.Tiles, next)
+		l.on[next] = true
+		for _, nd := range connDirs(m.at(next)) {
+			if nd != opp[d] {
+				d = nd
+				break
+			}
+		}
+		cur = next
+	}
+}
+
+// StartLoop is the loop through S.
+func (m *Maze) StartLoop() (*Loop, error) {
+	if !m.hasS {
+		return nil, fmt.Errorf("maze has no S tile")
+	}
+	return m.LoopFrom(m.start)
+}
+
+// Loops finds every closed loop in the maze, in reading order of their
+// first tile. Pipes that dead-end or leave the grid belong to no loop.
+func (m *Maze) Loops() []*Loop {
+	var loops []*Loop
+	seen := make(map[p]bool)
+	for r := range m.h {
+		for c := range m.w {
+			x := p{r, c}
+			if seen[x] || connDirs(m.grid[r][c]) == nil {
+				continue
+			}
+			l, err := m.LoopFrom(x)
+			if err != nil {
+				seen[x] = true
+				continue
+			}
+			for _, t := range l.Tiles {
+				seen[t] = true
+			}
+			loops = append(loops, l)
+		}
+	}
+	return loops
+}
+
+func (l *Loop) Contains(x p) bool { return l.on[x] }
+
+// Farthest is the tile reached last when walking both ways round the loop
+// from Tiles[0], and how many steps that takes.
+func (l *Loop) Farthest() (p, int) {
+	half := len(l.Tiles) / 2
+	return l.Tiles[half], half
+}
+
+// EnclosedRaycast collects the tiles inside the loop by scanning each row
+// and toggling at every loop tile with a north-facing connection: a ray
+// along the row crosses the boundary exactly there.
+func (l *Loop) EnclosedRaycast(m *Maze) []p {
+	var inside []p
+	for r := range m.h {
+		crossing := false
+		for c := range m.w {
+			x := p{r, c}
+			if l.on[x] {
+				if connectsBack(m.grid[r][c], N) {
+					crossing = !crossing
+				}
+			} else if crossing {
+				inside = append(inside, x)
+			}
+		}
+	}
+	return inside
+}
+
+// EnclosedCount counts the inside tiles without visiting them. The
+// shoelace formula gives the polygon's area through the tile centres, and
+// Pick's theorem turns that into interior lattice points: I = A - B/2 + 1.
+func (l *Loop) EnclosedCount() int {
+	area := 0
+	for i, a := range l.Tiles {
+		b := l.Tiles[(i+1)%len(l.Tiles)]
+		area += a.c*b.r - b.c*a.r
+	}
+	area = max(area, -area)
+	return area/2 - len(l.Tiles)/2 + 1
+}
+
+var boxGlyphs = map[rune]rune{
+	'|': '│', '-': '─', 'L': '└', 'J': '┘', '7': '┐', 'F': '┌',
+}
+
+// Render draws the loops with box-drawing glyphs, blanks every other pipe
+// and marks the tiles in inside with 'I'.
+func (m *Maze) Render(loops []*Loop, inside []p) []string {
+	out := make([][]rune, m.h)
+	for r := range out {
+		out[r] = []rune(fmt.Sprintf("%*s", m.w, ""))
+	}
+	for _, l := range loops {
+		for _, t := range l.Tiles {
+			out[t.r][t.c] = boxGlyphs[m.grid[t.r][t.c]]
+		}
+	}
+	for _, t := range inside {
+		out[t.r][t.c] = 'I'
+	}
+	if m.hasS {
+		out[m.start.r][m.start.c] = 'S'
+	}
+	lines := make([]string, m.h)
+	for r, row := range out {
+		lines[r] = string(row)
+	}
+	return lines
+}
@@ -2,117 +2,115 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"math/big"
 	"os"
-	"strconv"
 	"strings"
 )
 
-type MemoKey struct {
-	pos       int
-	groupIdx  int
-	groupSize int
-}
+func main() {
+	factor := flag.Int("unfold", 5, "copies of each record in part 2")
+	sep := flag.String("sep", "?", "cell joining the unfolded copies")
+	deduce := flag.Bool("deduce", false, "print the cells forced in every arrangement")
+	puzzle := flag.String("nonogram", "", "solve the nonogram in this file instead")
+	showStats := flag.Bool("stats", false, "print DP table statistics")
+	flag.Parse()
 
-func countArrangements(springs string, groups []int, memo map[MemoKey]int, pos, groupIdx, groupSize int) int {
-	key := MemoKey{pos, groupIdx, groupSize}
-	if val, exists := memo[key]; exists {
-		return val
+	if *puzzle != "" {
+		if err := solveNonogram(*puzzle); err != nil {
+			fmt.Println(err)
+		}
+		return
+	}
+	if len(*sep) != 1 {
+		fmt.Println("-sep must be a single cell")
+		return
 	}
 
-	if pos == len(springs) {
-		if groupIdx == len(groups) && groupSize == 0 {
-			return 1
+	var texts []string
+	var lines []Line
+	file, _ := os.Open("input.txt")
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
 		}
-		if groupIdx == len(groups)-1 && groupSize == groups[groupIdx] {
-			return 1
+		line, err := ParseLine(text)
+		if err != nil {
+			fmt.Println(err)
+			return
 		}
-		return 0
-	}
-	result := 0
-	current := springs[pos]
-
-	possibilities := []rune{rune(current)}
-
-	if current == '?' {
-		possibilities = []rune{'.', '#'}
+		texts = append(texts, text)
+		lines = append(lines, line)
 	}
-
-	for _, char := range possibilities {
-		switch char {
-		case '.':
-			if groupSize == 0 {
-				result += countArrangements(springs, groups, memo, pos+1, groupIdx, 0)
-			} else if groupIdx < len(groups) && groupSize == groups[groupIdx] {
-				result += countArrangements(springs, groups, memo, pos+1, groupIdx+1, 0)
-			}
-		case '#':
-			if groupIdx < len(groups) && groupSize < groups[groupIdx] {
-				result += countArrangements(springs, groups, memo, pos+1, groupIdx, groupSize+1)
+	var stats1, stats2 partStats
+	total := new(big.Int)
+	for i, line := range lines {
+		arrangements, stats := line.CountStats()
+		stats1.add(stats)
+		fmt.Printf("%s -> %d arrangements", texts[i], arrangements)
+		if *deduce {
+			if forced, err := line.Deduce(); err == nil {
+				fmt.Printf(" (%s)", forced)
 			}
 		}
+		fmt.Println()
+		total.Add(total, arrangements)
 	}
-	memo[key] = result
-	return result
-}
+	fmt.Printf("\nTotal arrangements: %d\n", total)
 
-func solveLine(line string, unfold bool) int {
-	parts := strings.Fields(line)
-	if len(parts) != 2 {
-		return 0
+	totalPart2 := new(big.Int)
+	for _, line := range lines {
+		arrangements, stats := line.Unfold(*factor, (*sep)[0]).CountStats()
+		stats2.add(stats)
+		totalPart2.Add(totalPart2, arrangements)
 	}
+	fmt.Printf("\nPart2 Total = %d\n", totalPart2)
+	if *showStats {
+		fmt.Println("Part 1 DP:", stats1)
+		fmt.Println("Part 2 DP:", stats2)
+	}
+}
 
-	springs := parts[0]
-	groupStrs := strings.Split(parts[1], ",")
-	groups := make([]int, len(groupStrs))
+// partStats sums the DP tables of every line in a part and keeps the size
+// of the largest one.
+type partStats struct {
+	TableStats
+	largest int
+}
 
-	for i, s := range groupStrs {
-		val, err := strconv.Atoi(s)
-		if err != nil {
-			return 0
-		}
-		groups[i] = val
-	}
-	if unfold {
-		unfoldedSprings := make([]string, 5)
-		for i := range 5 {
-			unfoldedSprings[i] = springs
-		}
-		springs = strings.Join(unfoldedSprings, "?")
-		originalGroups := make([]int, len(groups))
-		copy(originalGroups, groups)
-		groups = make([]int, 0, len(originalGroups)*5)
-		for range 5 {
-			groups = append(groups, originalGroups...)
-		}
-	}
-	memo := make(map[MemoKey]int)
-	return countArrangements(springs, groups, memo, 0, 0, 0)
+func (p *partStats) add(s TableStats) {
+	p.States += s.States
+	p.Reachable += s.Reachable
+	p.largest = max(p.largest, s.States)
 }
 
-func main() {
-	var lines []string
-	file, _ := os.Open("input.txt")
+func (p partStats) String() string {
+	return fmt.Sprintf("%s, largest table %d", p.TableStats, p.largest)
+}
+
+func solveNonogram(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
 	defer file.Close()
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line != "" {
-			lines = append(lines, line)
-		}
+	p, err := ReadNonogram(file)
+	if err != nil {
+		return err
 	}
-	total := 0
-	for _, line := range lines {
-		arrangements := solveLine(line, false)
-		fmt.Printf("%s -> %d arrangements\n", line, arrangements)
-		total += arrangements
+	solutions := p.Solve(2)
+	switch len(solutions) {
+	case 0:
+		return ErrContradiction
+	case 2:
+		fmt.Println("Solution is not unique; showing the first.")
 	}
-	fmt.Printf("\nTotal arrangements: %d\n", total)
-
-	totalPart2 := 0
-	for _, line := range lines {
-		arrangements := solveLine(line, true)
-		totalPart2 += arrangements
+	for _, row := range solutions[0] {
+		fmt.Println(row)
 	}
-	fmt.Printf("\nPart2 Total = %d\n", totalPart2)
+	return nil
 }
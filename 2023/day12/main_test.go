@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+type testCase struct {
+	record      string
+	expectPart1 int
+	expectPart2 int
+}
+
+var testCases = []testCase{
+	{"???.### 1,1,3", 1, 1},
+	{".??..??...?##. 1,1,3", 4, 16384},
+	{"?#?#?#?#?#?#?#? 1,3,1,6", 1, 1},
+	{"????.#...#... 4,1,1", 1, 16},
+	{"????.######..#####. 1,6,5", 4, 2500},
+	{"?###???????? 3,2,1", 10, 506250},
+}
+
+func TestCount(t *testing.T) {
+	total1, total2 := 0, 0
+	for _, tc := range testCases {
+		line, err := ParseLine(tc.record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got1 := int(line.Count().Int64())
+		if got1 != tc.expectPart1 {
+			t.Errorf("Part1: For %s: expected %d, got %d", tc.record, tc.expectPart1, got1)
+		}
+		got2 := int(line.Unfold(5, '?').Count().Int64())
+		if got2 != tc.expectPart2 {
+			t.Errorf("Part2: For %s: expected %d, got %d", tc.record, tc.expectPart2, got2)
+		}
+		total1 += got1
+		total2 += got2
+	}
+	if total1 != 21 || total2 != 525152 {
+		t.Errorf("expected totals 21 and 525152, got %d and %d", total1, total2)
+	}
+}
+
+// slowCount counts arrangements by the usual memoised recursion over the
+// remaining cells and clues, all in big.Int.
+func slowCount(cells string, clues []int, memo map[[2]int]*big.Int) *big.Int {
+	key := [2]int{len(cells), len(clues)}
+	if v, ok := memo[key]; ok {
+		return v
+	}
+	ways := new(big.Int)
+	switch {
+	case len(cells) == 0:
+		if len(clues) == 0 {
+			ways.SetInt64(1)
+		}
+	default:
+		if cells[0] != '#' {
+			ways.Add(ways, slowCount(cells[1:], clues, memo))
+		}
+		if len(clues) > 0 && cells[0] != '.' {
+			k := clues[0]
+			if k <= len(cells) && !strings.Contains(cells[:k], ".") {
+				switch {
+				case k == len(cells):
+					ways.Add(ways, slowCount("", clues[1:], memo))
+				case cells[k] != '#':
+					ways.Add(ways, slowCount(cells[k+1:], clues[1:], memo))
+				}
+			}
+		}
+	}
+	memo[key] = ways
+	return ways
+}
+
+func TestCountLargeFactor(t *testing.T) {
+	line, _ := ParseLine("?###???????? 3,2,1")
+	for _, factor := range []int{5, 19, 20, 25, 40} {
+		unfolded := line.Unfold(factor, '?')
+		want := slowCount(unfolded.Cells, unfolded.Clues, make(map[[2]int]*big.Int))
+		got, stats := unfolded.CountStats()
+		if got.Cmp(want) != 0 {
+			t.Errorf("factor %d: Count = %s, want %s", factor, got, want)
+		}
+		if stats.Reachable == 0 || stats.Reachable > stats.States {
+			t.Errorf("factor %d: stats %s", factor, stats)
+		}
+	}
+	if got := line.Unfold(20, '?').Count(); got.IsUint64() {
+		t.Errorf("factor 20 gave %s, which should not fit in a uint64", got)
+	}
+}
+
+func TestUnfold(t *testing.T) {
+	line, _ := ParseLine(".# 1")
+	got := line.Unfold(3, '.')
+	if got.Cells != ".#..#..#" || len(got.Clues) != 3 {
+		t.Errorf("expected .#..#..# with 3 clues, got %s %v", got.Cells, got.Clues)
+	}
+	if n := got.Count(); n.Int64() != 1 {
+		t.Errorf("expected 1 arrangement, got %d", n)
+	}
+}
+
+func TestDeduce(t *testing.T) {
+	deductions := []struct {
+		record string
+		want   string
+	}{
+		{"???.### 1,1,3", "#.#.###"},
+		{"?###???????? 3,2,1", ".###.???????"},
+		{"?????????? 8", "??######??"},
+		{"????? 0", "....."},
+		{"?????#?..?#? 2,2,2", "?????#?..?#?"},
+	}
+	for _, d := range deductions {
+		line, err := ParseLine(d.record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := line.Deduce()
+		if err != nil {
+			t.Errorf("For %s: unexpected error %v", d.record, err)
+			continue
+		}
+		if got != d.want {
+			t.Errorf("For %s: expected %s, got %s", d.record, d.want, got)
+		}
+	}
+}
+
+func TestContradiction(t *testing.T) {
+	for _, record := range []string{"#.# 3", "??? 2,1", "##? 1"} {
+		line, _ := ParseLine(record)
+		if n := line.Count(); n.Sign() != 0 {
+			t.Errorf("For %s: expected 0 arrangements, got %d", record, n)
+		}
+		if _, err := line.Deduce(); !errors.Is(err, ErrContradiction) {
+			t.Errorf("For %s: expected ErrContradiction, got %v", record, err)
+		}
+	}
+}
+
+func TestNonogram(t *testing.T) {
+	// A 5x5 heart, which line deduction alone solves.
+	puzzle := "1,1\n5\n5\n3\n1\n\n2\n4\n4\n4\n2\n"
+	want := []string{
+		".#.#.",
+		"#####",
+		"#####",
+		".###.",
+		"..#..",
+	}
+	p, err := ReadNonogram(strings.NewReader(puzzle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	solutions := p.Solve(2)
+	if len(solutions) != 1 {
+		t.Fatalf("expected 1 solution, got %d: %v", len(solutions), solutions)
+	}
+	for i, row := range solutions[0] {
+		if row != want[i] {
+			t.Errorf("expected\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(solutions[0], "\n"))
+			break
+		}
+	}
+
+	// Two diagonals fit the same clues.
+	p, _ = ReadNonogram(strings.NewReader("1\n1\n\n1\n1\n"))
+	if n := len(p.Solve(10)); n != 2 {
+		t.Errorf("expected 2 solutions, got %d", n)
+	}
+
+	p, _ = ReadNonogram(strings.NewReader("2\n0\n\n1\n1\n1\n"))
+	if n := len(p.Solve(10)); n != 0 {
+		t.Errorf("expected no solution, got %d", n)
+	}
+}
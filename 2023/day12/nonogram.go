@@ -0,0 +1,270 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+const (
+	filled  = '#'
+	empty   = '.'
+	unknown = '?'
+)
+
+var ErrContradiction = errors.New("no arrangement fits the clues")
+
+// Line is one row of springs, or one line of a nonogram: cells that are
+// filled, empty or unknown, and the lengths of the filled runs in order.
+type Line struct {
+	Cells string
+	Clues []int
+}
+
+// ParseLine reads "cells clues" with the clues comma-separated. A clue
+// list of "0" or nothing means no filled runs at all.
+func ParseLine(s string) (Line, error) {
+	parts := strings.Fields(s)
+	if len(parts) < 1 || len(parts) > 2 {
+		return Line{}, fmt.Errorf("want cells and clues, got %q", s)
+	}
+	clues, err := parseClues(strings.Join(parts[1:], ""))
+	if err != nil {
+		return Line{}, err
+	}
+	return Line{parts[0], clues}, nil
+}
+
+func parseClues(s string) ([]int, error) {
+	var clues []int
+	for _, f := range strings.Split(s, ",") {
+		if f == "" || f == "0" {
+			continue
+		}
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("bad clue %q", f)
+		}
+		clues = append(clues, n)
+	}
+	return clues, nil
+}
+
+// Unfold repeats the line factor times, joining the copies of the cells
+// with sep.
+func (l Line) Unfold(factor int, sep byte) Line {
+	cells := make([]string, factor)
+	var clues []int
+	for i := range factor {
+		cells[i] = l.Cells
+		clues = append(clues, l.Clues...)
+	}
+	return Line{strings.Join(cells, string(sep)), clues}
+}
+
+// The DP walks states (i, g): cells before i are settled, i is free to
+// start a run, and the first g clues are placed. From there a cell is
+// either skipped as empty or starts run g, which also takes the empty
+// cell after it.
+
+// fits reports whether run g can start at cell i, returning the state it
+// leads to.
+func (l Line) fits(i, g int) (int, bool) {
+	k := l.Clues[g]
+	if i+k > len(l.Cells) || strings.IndexByte(l.Cells[i:i+k], empty) >= 0 {
+		return 0, false
+	}
+	if i+k == len(l.Cells) {
+		return i + k, true
+	}
+	return i + k + 1, l.Cells[i+k] != filled
+}
+
+// Count is the number of ways to fill the unknown cells so the runs match
+// the clues. It grows exponentially with the unfold factor, so it is kept
+// in a uint64 only while it fits.
+func (l Line) Count() *big.Int {
+	ways, _ := l.CountStats()
+	return ways
+}
+
+// TableStats describes the DP table behind a count: its size and how many
+// of its states can be reached from the start.
+type TableStats struct {
+	States, Reachable int
+}
+
+func (s TableStats) String() string {
+	return fmt.Sprintf("%d states, %d reachable", s.States, s.Reachable)
+}
+
+// next calls visit with every state that (i, g) leads to.
+func (l Line) next(i, g int, visit func(j, h int)) {
+	if l.Cells[i] != filled {
+		visit(i+1, g)
+	}
+	if g < len(l.Clues) {
+		if j, ok := l.fits(i, g); ok {
+			visit(j, g+1)
+		}
+	}
+}
+
+// CountStats is Count along with statistics on the table it filled.
+func (l Line) CountStats() (*big.Int, TableStats) {
+	if ways, stats, ok := l.smallCount(); ok {
+		return new(big.Int).SetUint64(ways), stats
+	}
+	return l.bigCount()
+}
+
+// smallCount fills the table in uint64s and gives up on the first carry.
+func (l Line) smallCount() (uint64, TableStats, bool) {
+	n, groups := len(l.Cells), len(l.Clues)
+	ways := make([][]uint64, n+1)
+	for i := range ways {
+		ways[i] = make([]uint64, groups+1)
+	}
+	stats := TableStats{States: (n + 1) * (groups + 1)}
+	ways[0][0] = 1
+	overflow := false
+	for i := range n {
+		for g := range groups + 1 {
+			w := ways[i][g]
+			if w == 0 {
+				continue
+			}
+			stats.Reachable++
+			l.next(i, g, func(j, h int) {
+				var carry uint64
+				if ways[j][h], carry = bits.Add64(ways[j][h], w, 0); carry != 0 {
+					overflow = true
+				}
+			})
+			if overflow {
+				return 0, TableStats{}, false
+			}
+		}
+	}
+	for _, w := range ways[n] {
+		if w != 0 {
+			stats.Reachable++
+		}
+	}
+	return ways[n][groups], stats, true
+}
+
+func (l Line) bigCount() (*big.Int, TableStats) {
+	n, groups := len(l.Cells), len(l.Clues)
+	ways := make([][]*big.Int, n+1)
+	for i := range ways {
+		ways[i] = make([]*big.Int, groups+1)
+		for g := range ways[i] {
+			ways[i][g] = new(big.Int)
+		}
+	}
+	stats := TableStats{States: (n + 1) * (groups + 1)}
+	ways[0][0].SetInt64(1)
+	for i := range n {
+		for g := range groups + 1 {
+			w := ways[i][g]
+			if w.Sign() == 0 {
+				continue
+			}
+			stats.Reachable++
+			l.next(i, g, func(j, h int) { ways[j][h].Add(ways[j][h], w) })
+		}
+	}
+	for _, w := range ways[n] {
+		if w.Sign() != 0 {
+			stats.Reachable++
+		}
+	}
+	return ways[n][groups], stats
+}
+
+// Deduce fills in every unknown cell that takes the same value in all
+// arrangements, and fails if there are none. It tracks only which states
+// are possible, so it cannot overflow on long lines.
+func (l Line) Deduce() (string, error) {
+	n, groups := len(l.Cells), len(l.Clues)
+	grid := func() [][]bool {
+		t := make([][]bool, n+1)
+		for i := range t {
+			t[i] = make([]bool, groups+1)
+		}
+		return t
+	}
+	from, to := grid(), grid()
+	from[0][0] = true
+	for i := range n {
+		for g := range groups + 1 {
+			if !from[i][g] {
+				continue
+			}
+			if l.Cells[i] != filled {
+				from[i+1][g] = true
+			}
+			if g < groups {
+				if j, ok := l.fits(i, g); ok {
+					from[j][g+1] = true
+				}
+			}
+		}
+	}
+	if !from[n][groups] {
+		return "", ErrContradiction
+	}
+	to[n][groups] = true
+	for i := n - 1; i >= 0; i-- {
+		for g := range groups + 1 {
+			if l.Cells[i] != filled && to[i+1][g] {
+				to[i][g] = true
+			}
+			if g < groups {
+				if j, ok := l.fits(i, g); ok && to[j][g+1] {
+					to[i][g] = true
+				}
+			}
+		}
+	}
+
+	// Mark what each transition on a complete path paints. Runs are added
+	// to a difference array so long ones cost no more than short ones.
+	canEmpty := make([]bool, n)
+	runs := make([]int, n+1)
+	for i := range n {
+		for g := range groups + 1 {
+			if !from[i][g] {
+				continue
+			}
+			if l.Cells[i] != filled && to[i+1][g] {
+				canEmpty[i] = true
+			}
+			if g < groups {
+				if j, ok := l.fits(i, g); ok && to[j][g+1] {
+					runs[i]++
+					runs[i+l.Clues[g]]--
+					if end := i + l.Clues[g]; end < n {
+						canEmpty[end] = true
+					}
+				}
+			}
+		}
+	}
+	out := []byte(l.Cells)
+	depth := 0
+	for i := range n {
+		depth += runs[i]
+		switch canFill := depth > 0; {
+		case canFill && !canEmpty[i]:
+			out[i] = filled
+		case !canFill && canEmpty[i]:
+			out[i] = empty
+		}
+	}
+	return string(out), nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Nonogram is a grid puzzle: each row and column has clues for its runs
+// of filled cells.
+type Nonogram struct {
+	Rows, Cols [][]int
+}
+
+// ReadNonogram reads the row clues one per line, a blank line, then the
+// column clues. Each line is comma-separated; "0" is a line with no runs.
+func ReadNonogram(r io.Reader) (*Nonogram, error) {
+	p := &Nonogram{}
+	section := &p.Rows
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			if len(p.Rows) > 0 {
+				section = &p.Cols
+			}
+			continue
+		}
+		clues, err := parseClues(line)
+		if err != nil {
+			return nil, err
+		}
+		*section = append(*section, clues)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(p.Rows) == 0 || len(p.Cols) == 0 {
+		return nil, fmt.Errorf("want row clues and column clues")
+	}
+	return p, nil
+}
+
+// propagate deduces each row and column in turn until nothing changes.
+func (p *Nonogram) propagate(grid [][]byte) error {
+	for changed := true; changed; {
+		changed = false
+		for r, clues := range p.Rows {
+			cells, err := Line{string(grid[r]), clues}.Deduce()
+			if err != nil {
+				return err
+			}
+			if cells != string(grid[r]) {
+				copy(grid[r], cells)
+				changed = true
+			}
+		}
+		for c, clues := range p.Cols {
+			col := make([]byte, len(grid))
+			for r := range grid {
+				col[r] = grid[r][c]
+			}
+			cells, err := Line{string(col), clues}.Deduce()
+			if err != nil {
+				return err
+			}
+			for r := range grid {
+				if grid[r][c] != cells[r] {
+					grid[r][c] = cells[r]
+					changed = true
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Solve returns up to limit solutions. Line deduction alone solves most
+// published puzzles; when it stalls, the first unknown cell is guessed
+// both ways.
+func (p *Nonogram) Solve(limit int) [][]string {
+	grid := make([][]byte, len(p.Rows))
+	for r := range grid {
+		grid[r] = []byte(strings.Repeat(string(unknown), len(p.Cols)))
+	}
+	var solutions [][]string
+	var search func(grid [][]byte)
+	search = func(grid [][]byte) {
+		if len(solutions) >= limit || p.propagate(grid) != nil {
+			return
+		}
+		for r, row := range grid {
+			if c := strings.IndexByte(string(row), unknown); c >= 0 {
+				for _, guess := range []byte{filled, empty} {
+					next := make([][]byte, len(grid))
+					for i := range grid {
+						next[i] = append([]byte(nil), grid[i]...)
+					}
+					next[r][c] = guess
+					search(next)
+				}
+				return
+			}
+		}
+		rows := make([]string, len(grid))
+		for r := range grid {
+			rows[r] = string(grid[r])
+		}
+		solutions = append(solutions, rows)
+	}
+	search(grid)
+	return solutions
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"strings"
@@ -29,87 +30,13 @@ func parseInput(input string) []Pattern {
 	return patterns
 }
 
-// Check if there's a vertical reflection line between columns left and left+1
-func (p *Pattern) hasVerticalReflection(left int) bool {
-	return p.countVerticalMismatches(left) == 0
-}
-
-// Check if there's a horizontal reflection line between rows above and above+1
-func (p *Pattern) hasHorizontalReflection(above int) bool {
-	return p.countHorizontalMismatches(above) == 0
-}
-
-// Count mismatches for vertical reflection (for smudge detection)
-func (p *Pattern) countVerticalMismatches(left int) int {
-	mismatches := 0
-	maxExtent := min(left+1, p.cols-left-1)
-
-	for row := 0; row < p.rows; row++ {
-		for offset := range maxExtent {
-			leftCol := left - offset
-			rightCol := left + 1 + offset
-
-			if p.grid[row][leftCol] != p.grid[row][rightCol] {
-				mismatches++
-			}
-		}
-	}
-	return mismatches
-}
-
-// Count mismatches for horizontal reflection (for smudge detection)
-func (p *Pattern) countHorizontalMismatches(above int) int {
-	mismatches := 0
-	maxExtent := min(above+1, p.rows-above-1)
-
-	for offset := range maxExtent {
-		topRow := above - offset
-		bottomRow := above + 1 + offset
-
-		for col := 0; col < p.cols; col++ {
-			if p.grid[topRow][col] != p.grid[bottomRow][col] {
-				mismatches++
-			}
-		}
-	}
-	return mismatches
-}
-
-// Find reflection with exactly one smudge (one mismatch)
-func (p *Pattern) findSmudgedReflection() int {
-	// Try vertical reflections (between columns)
-	for col := 0; col < p.cols-1; col++ {
-		if p.countVerticalMismatches(col) == 1 {
-			return col + 1 // columns to the left
-		}
-	}
-
-	// Try horizontal reflections (between rows)
-	for row := 0; row < p.rows-1; row++ {
-		if p.countHorizontalMismatches(row) == 1 {
-			return 100 * (row + 1) // 100 * rows above
-		}
-	}
-
-	return 0 // No smudged reflection found
-}
-
-func (p *Pattern) findReflection() int {
-	// Try vertical reflections (between columns)
-	for col := 0; col < p.cols-1; col++ {
-		if p.hasVerticalReflection(col) {
-			return col + 1 // columns to the left
-		}
+// score is the summary of the first reflection with exactly smudges
+// mismatches, or 0 if there is none.
+func (p *Pattern) score(smudges int) int {
+	if refs := p.Reflections(smudges); len(refs) > 0 {
+		return refs[0].Score()
 	}
-
-	// Try horizontal reflections (between rows)
-	for row := 0; row < p.rows-1; row++ {
-		if p.hasHorizontalReflection(row) {
-			return 100 * (row + 1) // 100 * rows above
-		}
-	}
-
-	return 0 // No reflection found
+	return 0
 }
 
 func solvePart1(input string) int {
@@ -117,7 +44,7 @@ func solvePart1(input string) int {
 	total := 0
 
 	for i, pattern := range patterns {
-		score := pattern.findReflection()
+		score := pattern.score(0)
 		fmt.Printf("Pattern %d (Part 1): score = %d\n", i+1, score)
 		total += score
 	}
@@ -130,7 +57,7 @@ func solvePart2(input string) int {
 	total := 0
 
 	for i, pattern := range patterns {
-		score := pattern.findSmudgedReflection()
+		score := pattern.score(1)
 		fmt.Printf("Pattern %d (Part 2): score = %d\n", i+1, score)
 		total += score
 	}
@@ -139,6 +66,9 @@ func solvePart2(input string) int {
 }
 
 func main() {
+	list := flag.Int("list", -1, "list every reflection and symmetry with this many mismatches")
+	flag.Parse()
+
 	input, err := os.ReadFile("input.txt")
 	if err != nil {
 		fmt.Printf("Error reading input.txt: %v\n", err)
@@ -152,4 +82,13 @@ func main() {
 	fmt.Println("=== Part 2 ===")
 	result2 := solvePart2(string(input))
 	fmt.Printf("Part 2 Result: %d\n", result2)
+
+	if *list >= 0 {
+		fmt.Printf("\n=== Symmetries with %d mismatches ===\n", *list)
+		for i, pattern := range parseInput(string(input)) {
+			for _, r := range append(pattern.Reflections(*list), pattern.Symmetries(*list)...) {
+				fmt.Printf("Pattern %d: %v\n", i+1, r)
+			}
+		}
+	}
 }
@@ -0,0 +1,133 @@
+package main
+
+import "fmt"
+
+// Axis names the kind of symmetry a Reflection describes. Vertical and
+// Horizontal are mirror lines between columns or rows; the rest map the
+// whole grid onto itself.
+type Axis int
+
+const (
+	Vertical Axis = iota
+	Horizontal
+	Diagonal     // top-left to bottom-right, square grids only
+	AntiDiagonal // top-right to bottom-left, square grids only
+	HalfTurn     // rotation by 180 degrees
+	QuarterTurn  // rotation by 90 degrees, square grids only
+)
+
+func (a Axis) String() string {
+	return [...]string{"vertical", "horizontal", "diagonal", "anti-diagonal", "half-turn", "quarter-turn"}[a]
+}
+
+type Cell struct {
+	Row, Col int
+}
+
+// Mismatch is a cell whose image under the symmetry holds a different
+// character. For mirrors and the half turn each pair is listed once.
+type Mismatch struct {
+	At, Image Cell
+}
+
+// Reflection is a symmetry of a Pattern. For mirror lines Index counts the
+// columns left of, or rows above, the line.
+type Reflection struct {
+	Axis       Axis
+	Index      int
+	Mismatches []Mismatch
+}
+
+// Score is the puzzle's summary: columns left of a vertical line, or 100
+// times rows above a horizontal one. Whole-grid symmetries score nothing.
+func (r Reflection) Score() int {
+	switch r.Axis {
+	case Vertical:
+		return r.Index
+	case Horizontal:
+		return 100 * r.Index
+	}
+	return 0
+}
+
+func (r Reflection) String() string {
+	s := r.Axis.String()
+	if r.Axis == Vertical || r.Axis == Horizontal {
+		s += fmt.Sprintf(" after %d", r.Index)
+	}
+	for _, m := range r.Mismatches {
+		s += fmt.Sprintf(" (%d,%d)≠(%d,%d)", m.At.Row, m.At.Col, m.Image.Row, m.Image.Col)
+	}
+	return s
+}
+
+// mismatches compares every cell that image maps inside the grid with its
+// image, giving up once there are more than limit differences. Cells are
+// visited in reading order, so for an involution skipping images that
+// come earlier lists each pair once.
+func (p *Pattern) mismatches(image func(Cell) (Cell, bool), involution bool, limit int) ([]Mismatch, bool) {
+	var found []Mismatch
+	for r := range p.rows {
+		for c := range p.cols {
+			at := Cell{r, c}
+			im, ok := image(at)
+			if !ok || p.grid[at.Row][at.Col] == p.grid[im.Row][im.Col] {
+				continue
+			}
+			if involution && (im.Row < r || im.Row == r && im.Col < c) {
+				continue
+			}
+			if found = append(found, Mismatch{at, im}); len(found) > limit {
+				return nil, false
+			}
+		}
+	}
+	return found, true
+}
+
+// Reflections returns every mirror line with exactly smudges mismatched
+// pairs, vertical lines first, each in order.
+func (p *Pattern) Reflections(smudges int) []Reflection {
+	var out []Reflection
+	try := func(axis Axis, index int, image func(Cell) (Cell, bool)) {
+		if m, ok := p.mismatches(image, true, smudges); ok && len(m) == smudges {
+			out = append(out, Reflection{axis, index, m})
+		}
+	}
+	for left := 1; left < p.cols; left++ {
+		try(Vertical, left, func(c Cell) (Cell, bool) {
+			col := 2*left - 1 - c.Col
+			return Cell{c.Row, col}, col >= 0 && col < p.cols
+		})
+	}
+	for above := 1; above < p.rows; above++ {
+		try(Horizontal, above, func(c Cell) (Cell, bool) {
+			row := 2*above - 1 - c.Row
+			return Cell{row, c.Col}, row >= 0 && row < p.rows
+		})
+	}
+	return out
+}
+
+// Symmetries returns the whole-grid symmetries that hold with exactly
+// smudges mismatches. A quarter turn is not an involution, so each of its
+// mismatched cells counts separately and one smudge shows up twice.
+func (p *Pattern) Symmetries(smudges int) []Reflection {
+	var out []Reflection
+	try := func(axis Axis, involution bool, image func(Cell) Cell) {
+		m, ok := p.mismatches(func(c Cell) (Cell, bool) { return image(c), true }, involution, smudges)
+		if ok && len(m) == smudges {
+			out = append(out, Reflection{Axis: axis, Mismatches: m})
+		}
+	}
+	n := p.rows
+	if p.rows == p.cols {
+		try(Diagonal, true, func(c Cell) Cell { return Cell{c.Col, c.Row} })
+		try(AntiDiagonal, true, func(c Cell) Cell { return Cell{n - 1 - c.Col, n - 1 - c.Row} })
+	}
+	try(HalfTurn, true, func(c Cell) Cell { return Cell{p.rows - 1 - c.Row, p.cols - 1 - c.Col} })
+	if p.rows == p.cols {
+		try(QuarterTurn, false, func(c Cell) Cell { return Cell{c.Col, n - 1 - c.Row} })
+	}
+	return out
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"aoc/cycle"
 )
 
 func main() {
@@ -137,26 +139,15 @@ func gridToString(grid [][]rune) string {
 }
 
 func spinCycles(grid [][]rune, cycles int) int {
-	seen := make(map[string]int)
-	loads := make([]int, 0)
-
-	for i := range cycles {
-		spinCycle(grid)
-
-		gridState := gridToString(grid)
-		if firstSeen, exists := seen[gridState]; exists {
-			// Found a cycle
-			cycleLength := i - firstSeen
-			remaining := (cycles - 1 - i) % cycleLength
-			targetIndex := firstSeen + remaining
-			return loads[targetIndex]
+	spun := func(g [][]rune) [][]rune {
+		next := make([][]rune, len(g))
+		for i, row := range g {
+			next[i] = append([]rune(nil), row...)
 		}
-
-		seen[gridState] = i
-		loads = append(loads, calculateLoad(grid))
+		spinCycle(next)
+		return next
 	}
-
-	return calculateLoad(grid)
+	return calculateLoad(cycle.FastForward(grid, spun, gridToString, cycles))
 }
 
 func calculateLoad(grid [][]rune) int {
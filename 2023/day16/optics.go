@@ -0,0 +1,310 @@
+package main
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Element is the behaviour of one kind of tile. Outputs gives the
+// directions a beam leaves in when it arrives travelling in; none means the
+// beam is absorbed. A portal instead sends the beam out of the other tile
+// with the same glyph, still travelling the same way.
+type Element struct {
+	Outputs func(in Direction) []Direction
+	Portal  bool
+}
+
+var elements = map[byte]Element{}
+
+func RegisterElement(glyph byte, e Element) {
+	elements[glyph] = e
+}
+
+func turn(m map[Direction]Direction) func(Direction) []Direction {
+	return func(in Direction) []Direction { return []Direction{m[in]} }
+}
+
+func split(axis []Direction) func(Direction) []Direction {
+	return func(in Direction) []Direction {
+		for _, d := range axis {
+			if d == in {
+				return []Direction{in}
+			}
+		}
+		return axis
+	}
+}
+
+func init() {
+	RegisterElement('.', Element{Outputs: func(in Direction) []Direction { return []Direction{in} }})
+	RegisterElement('/', Element{Outputs: turn(map[Direction]Direction{Right: Up, Left: Down, Up: Right, Down: Left})})
+	RegisterElement('\\', Element{Outputs: turn(map[Direction]Direction{Right: Down, Left: Up, Up: Left, Down: Right})})
+	RegisterElement('|', Element{Outputs: split([]Direction{Up, Down})})
+	RegisterElement('-', Element{Outputs: split([]Direction{Left, Right})})
+	// '+' passes the beam straight on and also splits it both ways sideways.
+	RegisterElement('+', Element{Outputs: func(in Direction) []Direction {
+		return []Direction{in, {in.dc, in.dr}, {-in.dc, -in.dr}}
+	}})
+	RegisterElement('#', Element{Outputs: func(Direction) []Direction { return nil }})
+	for g := byte('0'); g <= '9'; g++ {
+		RegisterElement(g, Element{Portal: true})
+	}
+}
+
+var directionIndex = map[Direction]int{Right: 0, Left: 1, Up: 2, Down: 3}
+
+// Optics is a grid of elements. Beam states are numbered so visited sets
+// can be flat slices.
+type Optics struct {
+	grid       []string
+	rows, cols int
+	partner    map[Position]Position
+}
+
+func NewOptics(grid []string) (*Optics, error) {
+	o := &Optics{grid: grid, rows: len(grid), cols: len(grid[0]), partner: make(map[Position]Position)}
+	portals := make(map[byte][]Position)
+	for r, line := range grid {
+		if len(line) != o.cols {
+			return nil, fmt.Errorf("row %d has %d tiles, want %d", r, len(line), o.cols)
+		}
+		for c := range len(line) {
+			e, ok := elements[line[c]]
+			if !ok {
+				return nil, fmt.Errorf("unknown element %q at %d,%d", line[c], r, c)
+			}
+			if e.Portal {
+				portals[line[c]] = append(portals[line[c]], Position{r, c})
+			}
+		}
+	}
+	for g, ps := range portals {
+		if len(ps) != 2 {
+			return nil, fmt.Errorf("portal %q appears %d times, want 2", g, len(ps))
+		}
+		o.partner[ps[0]], o.partner[ps[1]] = ps[1], ps[0]
+	}
+	return o, nil
+}
+
+func (o *Optics) inside(p Position) bool {
+	return p.row >= 0 && p.row < o.rows && p.col >= 0 && p.col < o.cols
+}
+
+func (o *Optics) cell(p Position) int { return p.row*o.cols + p.col }
+
+func (o *Optics) state(b Beam) int { return o.cell(b.pos)*4 + directionIndex[b.dir] }
+
+// next returns the beams leaving b's tile, dropping those that leave the
+// grid. A portal also energises its partner tile, returned as via.
+func (o *Optics) next(b Beam) (out []Beam, via *Position) {
+	e := elements[o.grid[b.pos.row][b.pos.col]]
+	from, dirs := b.pos, []Direction{b.dir}
+	if e.Portal {
+		p := o.partner[b.pos]
+		from, via = p, &p
+	} else {
+		dirs = e.Outputs(b.dir)
+	}
+	for _, d := range dirs {
+		p := Position{from.row + d.dr, from.col + d.dc}
+		if o.inside(p) {
+			out = append(out, Beam{p, d})
+		}
+	}
+	return out, via
+}
+
+// Energize follows a beam entering at start and counts the tiles it
+// touches, by direct simulation.
+func (o *Optics) Energize(start Beam) int {
+	seen := make([]bool, o.rows*o.cols*4)
+	lit := make(bitset, (o.rows*o.cols+63)/64)
+	queue := []Beam{start}
+	for len(queue) > 0 {
+		b := queue[0]
+		queue = queue[1:]
+		if s := o.state(b); seen[s] {
+			continue
+		} else {
+			seen[s] = true
+		}
+		lit.set(o.cell(b.pos))
+		out, via := o.next(b)
+		if via != nil {
+			lit.set(o.cell(*via))
+		}
+		queue = append(queue, out...)
+	}
+	return lit.count()
+}
+
+type bitset []uint64
+
+func (s bitset) set(i int) { s[i/64] |= 1 << (i % 64) }
+
+func (s bitset) or(t bitset) {
+	for i := range s {
+		s[i] |= t[i]
+	}
+}
+
+func (s bitset) count() int {
+	n := 0
+	for _, w := range s {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// segment is a stretch of beam with no choices in it: the tiles it lights
+// and the branch states where it ends.
+type segment struct {
+	lit  bitset
+	next []int
+}
+
+// branching reports whether b's tile sends the beam more than one way,
+// which makes b a node of the segment graph.
+func (o *Optics) branching(b Beam) bool {
+	out, _ := o.next(b)
+	return len(out) > 1
+}
+
+// trace follows the beams in from until each leaves the grid, is absorbed,
+// runs into a branch state or repeats itself.
+func (o *Optics) trace(from []Beam, nodes map[int]int) segment {
+	seg := segment{lit: make(bitset, (o.rows*o.cols+63)/64)}
+	seen := make(map[int]bool)
+	for _, b := range from {
+		for {
+			s := o.state(b)
+			if n, ok := nodes[s]; ok {
+				seg.next = append(seg.next, n)
+				break
+			}
+			if seen[s] {
+				break
+			}
+			seen[s] = true
+			seg.lit.set(o.cell(b.pos))
+			out, via := o.next(b)
+			if via != nil {
+				seg.lit.set(o.cell(*via))
+			}
+			if len(out) != 1 {
+				break
+			}
+			b = out[0]
+		}
+	}
+	return seg
+}
+
+// Segments compresses the beam paths into a graph whose nodes are the
+// branch states, each lighting its own segment. Strongly connected
+// components of that graph light the same tiles, so after condensing them
+// the tiles lit from any node are the union along a single pass of the
+// DAG, and every entry point is answered from that table.
+type Segments struct {
+	o     *Optics
+	nodes map[int]int
+	reach []bitset
+	comp  []int
+}
+
+func (o *Optics) Segments() *Segments {
+	g := &Segments{o: o, nodes: make(map[int]int)}
+	var branches []Beam
+	for r := range o.rows {
+		for c := range o.cols {
+			for _, d := range []Direction{Right, Left, Up, Down} {
+				b := Beam{Position{r, c}, d}
+				if o.branching(b) {
+					g.nodes[o.state(b)] = len(branches)
+					branches = append(branches, b)
+				}
+			}
+		}
+	}
+
+	segs := make([]segment, len(branches))
+	for i, b := range branches {
+		out, via := o.next(b)
+		segs[i] = o.trace(out, g.nodes)
+		segs[i].lit.set(o.cell(b.pos))
+		if via != nil {
+			segs[i].lit.set(o.cell(*via))
+		}
+	}
+
+	// Tarjan's algorithm emits components sinks first, so each one's
+	// successors are already complete when it is.
+	n := len(segs)
+	index, low := make([]int, n), make([]int, n)
+	onStack := make([]bool, n)
+	g.comp = make([]int, n)
+	for i := range index {
+		index[i] = -1
+	}
+	var stack []int
+	counter := 0
+	var strongConnect func(v int)
+	strongConnect = func(v int) {
+		index[v], low[v] = counter, counter
+		counter++
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, w := range segs[v].next {
+			if index[w] < 0 {
+				strongConnect(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+		if low[v] != index[v] {
+			return
+		}
+		c := len(g.reach)
+		lit := make(bitset, len(segs[v].lit))
+		var members []int
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			g.comp[w] = c
+			members = append(members, w)
+			lit.or(segs[w].lit)
+			if w == v {
+				break
+			}
+		}
+		g.reach = append(g.reach, lit)
+		for _, w := range members {
+			for _, x := range segs[w].next {
+				if cx := g.comp[x]; cx != c {
+					lit.or(g.reach[cx])
+				}
+			}
+		}
+	}
+	for v := range n {
+		if index[v] < 0 {
+			strongConnect(v)
+		}
+	}
+	return g
+}
+
+// Energize counts the tiles lit by a beam entering at start.
+func (g *Segments) Energize(start Beam) int {
+	if n, ok := g.nodes[g.o.state(start)]; ok {
+		return g.reach[g.comp[n]].count()
+	}
+	seg := g.o.trace([]Beam{start}, g.nodes)
+	for _, n := range seg.next {
+		seg.lit.or(g.reach[g.comp[n]])
+	}
+	return seg.lit.count()
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Engine runs parts through a validated, acyclic set of workflows.
+type Engine struct {
+	workflows map[string]Workflow
+	start     string
+	fields    []string
+}
+
+// Step is one rule firing while a part travels through the workflows.
+type Step struct {
+	Workflow string
+	Rule     int
+	Cond     Condition
+	Target   string
+}
+
+func (s Step) String() string {
+	if s.Cond == nil {
+		return fmt.Sprintf("%s[%d] default -> %s", s.Workflow, s.Rule, s.Target)
+	}
+	return fmt.Sprintf("%s[%d] %s -> %s", s.Workflow, s.Rule, s.Cond, s.Target)
+}
+
+func isTerminal(name string) bool { return name == "A" || name == "R" }
+
+// NewEngine checks that every target exists, that every workflow ends in
+// a default rule and that no workflow can reach itself.
+func NewEngine(workflows map[string]Workflow, start string) (*Engine, error) {
+	if _, ok := workflows[start]; !ok {
+		return nil, fmt.Errorf("start workflow %q not defined", start)
+	}
+
+	seen := make(map[string]bool)
+	for _, w := range workflows {
+		if len(w.Rules) == 0 || w.Rules[len(w.Rules)-1].Cond != nil {
+			return nil, fmt.Errorf("workflow %s has no default rule", w.Name)
+		}
+		for _, r := range w.Rules {
+			if _, ok := workflows[r.Target]; !ok && !isTerminal(r.Target) {
+				return nil, fmt.Errorf("workflow %s sends to unknown workflow %q", w.Name, r.Target)
+			}
+			if r.Cond != nil {
+				r.Cond.Fields(func(f string) { seen[f] = true })
+			}
+		}
+	}
+
+	e := &Engine{workflows: workflows, start: start}
+	if cycle := e.FindCycle(); cycle != nil {
+		return nil, fmt.Errorf("workflow cycle: %s", strings.Join(cycle, " -> "))
+	}
+	for f := range seen {
+		e.fields = append(e.fields, f)
+	}
+	sort.Strings(e.fields)
+	return e, nil
+}
+
+// Fields lists every field referenced by a condition, sorted.
+func (e *Engine) Fields() []string { return e.fields }
+
+// FindCycle returns a workflow loop as a closed path, or nil.
+func (e *Engine) FindCycle() []string {
+	const (
+		unvisited = iota
+		onStack
+		done
+	)
+	state := make(map[string]int)
+	var stack []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = onStack
+		stack = append(stack, name)
+		for _, r := range e.workflows[name].Rules {
+			if isTerminal(r.Target) {
+				continue
+			}
+			switch state[r.Target] {
+			case onStack:
+				for i, n := range stack {
+					if n == r.Target {
+						return append(append([]string{}, stack[i:]...), r.Target)
+					}
+				}
+			case unvisited:
+				if cycle := visit(r.Target); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = done
+		return nil
+	}
+
+	names := make([]string, 0, len(e.workflows))
+	for name := range e.workflows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if state[name] == unvisited {
+			if cycle := visit(name); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// Explain returns the rules a part fires on its way to A or R.
+func (e *Engine) Explain(p Part) (path []Step, accepted bool) {
+	current := e.start
+	for !isTerminal(current) {
+		for i, r := range e.workflows[current].Rules {
+			if r.Cond == nil || r.Cond.Eval(p) {
+				path = append(path, Step{current, i, r.Cond, r.Target})
+				current = r.Target
+				break
+			}
+		}
+	}
+	return path, current == "A"
+}
+
+func (e *Engine) Accepts(p Part) bool {
+	_, accepted := e.Explain(p)
+	return accepted
+}
+
+// defaultFields are the ratings of a part in the puzzle.
+var defaultFields = []string{"x", "m", "a", "s"}
+
+// Domain is a box giving each of fields the range [lo, hi]. Fields no rule
+// tests still multiply the count, so they must come from the part schema,
+// not from the workflows; any referenced field missing from the list is
+// added too.
+func (e *Engine) Domain(fields []string, lo, hi int) RangeSet {
+	rs := make(RangeSet, len(fields))
+	for _, f := range fields {
+		rs[f] = Range{lo, hi}
+	}
+	for _, f := range e.fields {
+		rs[f] = Range{lo, hi}
+	}
+	return rs
+}
+
+// CountAccepted returns how many parts in the box end up accepted.
+func (e *Engine) CountAccepted(rs RangeSet) int64 {
+	return e.countFrom(e.start, rs)
+}
+
+func (e *Engine) countFrom(name string, rs RangeSet) int64 {
+	if rs.IsEmpty() || name == "R" {
+		return 0
+	}
+	if name == "A" {
+		return rs.Combinations()
+	}
+
+	total := int64(0)
+	pending := []RangeSet{rs}
+	for _, r := range e.workflows[name].Rules {
+		if len(pending) == 0 {
+			break
+		}
+		if r.Cond == nil {
+			for _, box := range pending {
+				total += e.countFrom(r.Target, box)
+			}
+			break
+		}
+		var rest []RangeSet
+		for _, box := range pending {
+			match, miss := r.Cond.Split(box)
+			for _, m := range match {
+				total += e.countFrom(r.Target, m)
+			}
+			rest = append(rest, miss...)
+		}
+		pending = rest
+	}
+	return total
+}
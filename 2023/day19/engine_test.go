@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const example = `px{a<2006:qkq,m>2090:A,rfg}
+pv{a>1716:R,A}
+lnx{m>1548:A,A}
+rfg{s<537:gd,x>2440:R,A}
+qs{s>3448:A,lnx}
+qkq{x<1416:A,crn}
+crn{x>2662:A,R}
+in{s<1351:px,qqz}
+qqz{s>2770:qs,m<1801:hdj,R}
+gd{a>3333:R,R}
+hdj{m>838:A,pv}`
+
+func newEngine(t *testing.T, workflows string) *Engine {
+	t.Helper()
+	ws, err := parseWorkflows(strings.Split(workflows, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := NewEngine(ws, "in")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestCountAcceptedExample(t *testing.T) {
+	e := newEngine(t, example)
+	if got, want := e.CountAccepted(e.Domain(defaultFields, 1, 4000)), int64(167409079868000); got != want {
+		t.Errorf("CountAccepted = %d, want %d", got, want)
+	}
+}
+
+func TestDomainUnreferencedFields(t *testing.T) {
+	// Only x is ever tested; m, a and s still range over 1..4000.
+	e := newEngine(t, "in{x>10:A,R}")
+	got := e.CountAccepted(e.Domain(defaultFields, 1, 4000))
+	if want := int64(3990) * 4000 * 4000 * 4000; got != want {
+		t.Errorf("CountAccepted = %d, want %d", got, want)
+	}
+
+	// A referenced field left out of the list is still counted.
+	got = e.CountAccepted(e.Domain([]string{"m"}, 1, 20))
+	if want := int64(10 * 20); got != want {
+		t.Errorf("CountAccepted with a partial list = %d, want %d", got, want)
+	}
+}
+
+func TestPartFields(t *testing.T) {
+	parts := parseParts([]string{"{x=787,m=2655}", "{a=1,s=2}"}, 0)
+	if got := strings.Join(partFields(parts), ","); got != "a,m,s,x" {
+		t.Errorf("partFields = %s, want a,m,s,x", got)
+	}
+}
@@ -2,18 +2,17 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 )
 
 type Rule struct {
-	Field     string
-	Operator  string
-	Value     int
-	Target    string
-	IsDefault bool
+	Cond   Condition // nil for the trailing default rule
+	Target string
 }
 
 type Workflow struct {
@@ -21,34 +20,7 @@ type Workflow struct {
 	Rules []Rule
 }
 
-type Part struct {
-	X, M, A, S int
-}
-
-type Range struct {
-	Min, Max int
-}
-
-type RangeSet struct {
-	X, M, A, S Range
-}
-
-func (r Range) Size() int64 {
-	if r.Max < r.Min {
-		return 0
-	}
-	return int64(r.Max - r.Min + 1)
-}
-
-func (rs RangeSet) Combinations() int64 {
-	return rs.X.Size() * rs.M.Size() * rs.A.Size() * rs.S.Size()
-}
-
-func (rs RangeSet) IsEmpty() bool {
-	return rs.X.Max < rs.X.Min || rs.M.Max < rs.M.Min || rs.A.Max < rs.A.Min || rs.S.Max < rs.S.Min
-}
-
-func parseWorkflows(lines []string) map[string]Workflow {
+func parseWorkflows(lines []string) (map[string]Workflow, error) {
 	workflows := make(map[string]Workflow)
 	for _, line := range lines {
 		if line == "" {
@@ -59,47 +31,24 @@ func parseWorkflows(lines []string) map[string]Workflow {
 		rulesStr := strings.TrimSuffix(parts[1], "}")
 
 		var rules []Rule
-		ruleParts := strings.Split(rulesStr, ",")
-
-		for i, ruleStr := range ruleParts {
-			if i == len(ruleParts)-1 {
-				rules = append(rules, Rule{
-					Target:    ruleStr,
-					IsDefault: true,
-				})
-			} else {
-				colonIdx := strings.Index(ruleStr, ":")
-				condition := ruleStr[:colonIdx]
-				target := ruleStr[colonIdx+1:]
-
-				var field, operator string
-				var value int
-
-				if strings.Contains(condition, "<") {
-					parts := strings.Split(condition, "<")
-					field = parts[0]
-					operator = "<"
-					value, _ = strconv.Atoi(parts[1])
-				} else if strings.Contains(condition, ">") {
-					parts := strings.Split(condition, ">")
-					field = parts[0]
-					operator = ">"
-					value, _ = strconv.Atoi(parts[1])
-				}
-				rules = append(rules, Rule{
-					Field:    field,
-					Operator: operator,
-					Value:    value,
-					Target:   target,
-				})
+		for _, ruleStr := range strings.Split(rulesStr, ",") {
+			colonIdx := strings.Index(ruleStr, ":")
+			if colonIdx == -1 {
+				rules = append(rules, Rule{Target: ruleStr})
+				continue
 			}
+			cond, err := parseCondition(ruleStr[:colonIdx])
+			if err != nil {
+				return nil, fmt.Errorf("workflow %s: %w", name, err)
+			}
+			rules = append(rules, Rule{cond, ruleStr[colonIdx+1:]})
 		}
 		workflows[name] = Workflow{
 			name,
 			rules,
 		}
 	}
-	return workflows
+	return workflows, nil
 }
 
 func parseParts(lines []string, startIdx int) []Part {
@@ -113,166 +62,35 @@ func parseParts(lines []string, startIdx int) []Part {
 
 		line = strings.TrimPrefix(line, "{")
 		line = strings.TrimSuffix(line, "}")
-		ratings := strings.Split(line, ",")
 		part := Part{}
 
-		for _, rating := range ratings {
-			parts := strings.Split(rating, "=")
-			field := parts[0]
-			value, _ := strconv.Atoi(parts[1])
-
-			switch field {
-			case "x":
-				part.X = value
-			case "m":
-				part.M = value
-			case "a":
-				part.A = value
-			case "s":
-				part.S = value
-			}
+		for _, rating := range strings.Split(line, ",") {
+			field, value, _ := strings.Cut(rating, "=")
+			part[field], _ = strconv.Atoi(value)
 		}
 		parts = append(parts, part)
 	}
 	return parts
 }
 
-func evaluateRule(rule Rule, part Part) bool {
-	if rule.IsDefault {
-		return true
-	}
-
-	var fieldValue int
-	switch rule.Field {
-	case "x":
-		fieldValue = part.X
-	case "m":
-		fieldValue = part.M
-	case "a":
-		fieldValue = part.A
-	case "s":
-		fieldValue = part.S
-	}
-
-	switch rule.Operator {
-	case "<":
-		return fieldValue < rule.Value
-	case ">":
-		return fieldValue > rule.Value
-	}
-
-	return false
-}
-
-func processPart(part Part, workflows map[string]Workflow) bool {
-	currentWorkflow := "in"
-	for {
-		workflow := workflows[currentWorkflow]
-		for _, rule := range workflow.Rules {
-			if evaluateRule(rule, part) {
-				switch rule.Target {
-				case "A":
-					return true
-				case "R":
-					return false
-				default:
-					currentWorkflow = rule.Target
-					goto nextWorkflow
-				}
+// partFields lists every field rated in any part, sorted.
+func partFields(parts []Part) []string {
+	var fields []string
+	for _, p := range parts {
+		for f := range p {
+			if !slices.Contains(fields, f) {
+				fields = append(fields, f)
 			}
 		}
-	nextWorkflow:
-	}
-}
-
-func splitRange(rs RangeSet, rule Rule) (matching, nonMatching RangeSet) {
-	matching = rs
-	nonMatching = rs
-
-	if rule.IsDefault {
-		nonMatching = RangeSet{
-			Range{1, 0},
-			Range{1, 0},
-			Range{1, 0},
-			Range{1, 0},
-		}
-		return matching, nonMatching
-	}
-
-	var currentRange *Range
-	var matchingRange *Range
-	var nonMatchingRange *Range
-
-	switch rule.Field {
-	case "x":
-		currentRange = &rs.X
-		matchingRange = &matching.X
-		nonMatchingRange = &nonMatching.X
-	case "m":
-		currentRange = &rs.M
-		matchingRange = &matching.M
-		nonMatchingRange = &nonMatching.M
-	case "a":
-		currentRange = &rs.A
-		matchingRange = &matching.A
-		nonMatchingRange = &nonMatching.A
-	case "s":
-		currentRange = &rs.S
-		matchingRange = &matching.S
-		nonMatchingRange = &nonMatching.S
-	}
-	switch rule.Operator {
-	case "<":
-		if rule.Value <= currentRange.Min {
-			*matchingRange = Range{1, 0}
-		} else if rule.Value > currentRange.Max {
-			*nonMatchingRange = Range{1, 0}
-		} else {
-			*matchingRange = Range{currentRange.Min, rule.Value - 1}
-			*nonMatchingRange = Range{rule.Value, currentRange.Max}
-		}
-	case ">":
-		if rule.Value >= currentRange.Max {
-			*matchingRange = Range{1, 0}
-		} else if rule.Value < currentRange.Min {
-			*nonMatchingRange = Range{1, 0}
-		} else {
-			*matchingRange = Range{rule.Value + 1, currentRange.Max}
-			*nonMatchingRange = Range{currentRange.Min, rule.Value}
-		}
-	}
-	return matching, nonMatching
-}
-
-func countAcceptedCombinations(workflowName string, ranges RangeSet, workflows map[string]Workflow) int64 {
-	if ranges.IsEmpty() {
-		return 0
-	}
-	if workflowName == "A" {
-		return ranges.Combinations()
-	}
-	if workflowName == "R" {
-		return 0
-	}
-	workflow := workflows[workflowName]
-	currentRanges := ranges
-	totalAccepted := int64(0)
-
-	for _, rule := range workflow.Rules {
-		if currentRanges.IsEmpty() {
-			break
-		}
-		matching, nonMatching := splitRange(currentRanges, rule)
-
-		if !matching.IsEmpty() {
-			totalAccepted += countAcceptedCombinations(rule.Target, matching, workflows)
-		}
-		currentRanges = nonMatching
 	}
-	return totalAccepted
+	slices.Sort(fields)
+	return fields
 }
 
 func main() {
+	explain := flag.Bool("explain", false, "print the path each part takes through the workflows")
+	flag.Parse()
+
 	file, _ := os.Open("input.txt")
 	defer file.Close()
 	var lines []string
@@ -280,7 +98,7 @@ func main() {
 	for scanner.Scan() {
 		lines = append(lines, scanner.Text())
 	}
-	blankLineIdx := -1
+	blankLineIdx := len(lines)
 	for i, line := range lines {
 		if line == "" {
 			blankLineIdx = i
@@ -288,15 +106,38 @@ func main() {
 		}
 	}
 
-	workflows := parseWorkflows(lines[:blankLineIdx])
-	if blankLineIdx != -1 && blankLineIdx < len(lines)-1 {
+	workflows, err := parseWorkflows(lines[:blankLineIdx])
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	engine, err := NewEngine(workflows, "in")
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	fields := defaultFields
+	if blankLineIdx < len(lines)-1 {
 		parts := parseParts(lines, blankLineIdx+1)
+		if schema := partFields(parts); len(schema) > 0 {
+			fields = schema
+		}
 		totalRating := 0
 		acceptedCount := 0
 
 		for _, part := range parts {
-			if processPart(part, workflows) {
-				totalRating += part.X + part.M + part.A + part.S
+			path, accepted := engine.Explain(part)
+			if *explain {
+				fmt.Println(part)
+				for _, step := range path {
+					fmt.Println("  ", step)
+				}
+			}
+			if accepted {
+				for _, v := range part {
+					totalRating += v
+				}
 				acceptedCount++
 			}
 		}
@@ -304,12 +145,6 @@ func main() {
 		fmt.Printf("Part 1 - Total rating sum: %d\n", totalRating)
 	}
 
-	initialRanges := RangeSet{
-		Range{1, 4000},
-		Range{1, 4000},
-		Range{1, 4000},
-		Range{1, 4000},
-	}
-	acceptedCombinations := countAcceptedCombinations("in", initialRanges, workflows)
+	acceptedCombinations := engine.CountAccepted(engine.Domain(fields, 1, 4000))
 	fmt.Printf("Part 2 - Accepted Combinations: %d\n", acceptedCombinations)
 }
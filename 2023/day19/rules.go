@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type Part map[string]int
+
+func (p Part) String() string {
+	fields := make([]string, 0, len(p))
+	for f, v := range p {
+		fields = append(fields, f+"="+strconv.Itoa(v))
+	}
+	sort.Strings(fields)
+	return "{" + strings.Join(fields, ",") + "}"
+}
+
+type Range struct {
+	Min, Max int
+}
+
+// RangeSet is a box of parts: one inclusive range per named field.
+type RangeSet map[string]Range
+
+func (r Range) Size() int64 {
+	if r.Max < r.Min {
+		return 0
+	}
+	return int64(r.Max - r.Min + 1)
+}
+
+func (rs RangeSet) Combinations() int64 {
+	total := int64(1)
+	for _, r := range rs {
+		total *= r.Size()
+	}
+	return total
+}
+
+func (rs RangeSet) IsEmpty() bool {
+	for _, r := range rs {
+		if r.Max < r.Min {
+			return true
+		}
+	}
+	return false
+}
+
+func (rs RangeSet) with(field string, r Range) RangeSet {
+	out := make(RangeSet, len(rs))
+	for k, v := range rs {
+		out[k] = v
+	}
+	out[field] = r
+	return out
+}
+
+// Condition is a boolean expression over part fields. Split partitions a
+// box into disjoint boxes that do and do not satisfy it.
+type Condition interface {
+	Eval(p Part) bool
+	Split(rs RangeSet) (match, rest []RangeSet)
+	Fields(add func(string))
+	String() string
+}
+
+type Compare struct {
+	Field    string
+	Operator string
+	Value    int
+}
+
+type And struct{ L, R Condition }
+
+type Or struct{ L, R Condition }
+
+func (c Compare) Eval(p Part) bool {
+	v := p[c.Field]
+	switch c.Operator {
+	case "<":
+		return v < c.Value
+	case ">":
+		return v > c.Value
+	case "<=":
+		return v <= c.Value
+	case ">=":
+		return v >= c.Value
+	case "=":
+		return v == c.Value
+	case "!=":
+		return v != c.Value
+	}
+	return false
+}
+
+// intervals returns the satisfying ranges of the comparison, clipped to r.
+func (c Compare) intervals(r Range) (match, rest []Range) {
+	below := Range{r.Min, min(r.Max, c.Value-1)}
+	at := Range{max(r.Min, c.Value), min(r.Max, c.Value)}
+	above := Range{max(r.Min, c.Value+1), r.Max}
+
+	var in, out []Range
+	switch c.Operator {
+	case "<":
+		in, out = []Range{below}, []Range{at, above}
+	case "<=":
+		in, out = []Range{below, at}, []Range{above}
+	case ">":
+		in, out = []Range{above}, []Range{below, at}
+	case ">=":
+		in, out = []Range{at, above}, []Range{below}
+	case "=":
+		in, out = []Range{at}, []Range{below, above}
+	case "!=":
+		in, out = []Range{below, above}, []Range{at}
+	}
+	return nonEmpty(in), nonEmpty(out)
+}
+
+func nonEmpty(rs []Range) []Range {
+	var out []Range
+	for _, r := range rs {
+		if r.Size() > 0 {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func (c Compare) Split(rs RangeSet) (match, rest []RangeSet) {
+	in, out := c.intervals(rs[c.Field])
+	for _, r := range in {
+		match = append(match, rs.with(c.Field, r))
+	}
+	for _, r := range out {
+		rest = append(rest, rs.with(c.Field, r))
+	}
+	return match, rest
+}
+
+func (c Compare) Fields(add func(string)) { add(c.Field) }
+
+func (c Compare) String() string {
+	return c.Field + c.Operator + strconv.Itoa(c.Value)
+}
+
+func (a And) Eval(p Part) bool { return a.L.Eval(p) && a.R.Eval(p) }
+
+func (a And) Split(rs RangeSet) (match, rest []RangeSet) {
+	left, rest := a.L.Split(rs)
+	for _, box := range left {
+		m, r := a.R.Split(box)
+		match = append(match, m...)
+		rest = append(rest, r...)
+	}
+	return match, rest
+}
+
+func (a And) Fields(add func(string)) { a.L.Fields(add); a.R.Fields(add) }
+
+func (a And) String() string { return "(" + a.L.String() + "&" + a.R.String() + ")" }
+
+func (o Or) Eval(p Part) bool { return o.L.Eval(p) || o.R.Eval(p) }
+
+func (o Or) Split(rs RangeSet) (match, rest []RangeSet) {
+	match, left := o.L.Split(rs)
+	for _, box := range left {
+		m, r := o.R.Split(box)
+		match = append(match, m...)
+		rest = append(rest, r...)
+	}
+	return match, rest
+}
+
+func (o Or) Fields(add func(string)) { o.L.Fields(add); o.R.Fields(add) }
+
+func (o Or) String() string { return "(" + o.L.String() + "|" + o.R.String() + ")" }
+
+// parseCondition reads the rule DSL:
+//
+//	or      = and { "|" and }
+//	and     = atom { "&" atom }
+//	atom    = "(" or ")" | field op number
+//	op      = "<" | ">" | "<=" | ">=" | "=" | "!="
+func parseCondition(s string) (Condition, error) {
+	p := &condParser{src: s}
+	cond, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos != len(p.src) {
+		return nil, fmt.Errorf("condition %q: unexpected %q at %d", s, p.src[p.pos:], p.pos)
+	}
+	return cond, nil
+}
+
+type condParser struct {
+	src string
+	pos int
+}
+
+func (p *condParser) skipSpace() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *condParser) accept(tok string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.src[p.pos:], tok) {
+		p.pos += len(tok)
+		return true
+	}
+	return false
+}
+
+func (p *condParser) or() (Condition, error) {
+	left, err := p.and()
+	for err == nil && p.accept("|") {
+		var right Condition
+		if right, err = p.and(); err == nil {
+			left = Or{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *condParser) and() (Condition, error) {
+	left, err := p.atom()
+	for err == nil && p.accept("&") {
+		var right Condition
+		if right, err = p.atom(); err == nil {
+			left = And{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *condParser) atom() (Condition, error) {
+	if p.accept("(") {
+		cond, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("condition %q: missing ')' at %d", p.src, p.pos)
+		}
+		return cond, nil
+	}
+
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) && unicode.IsLetter(rune(p.src[p.pos])) {
+		p.pos++
+	}
+	field := p.src[start:p.pos]
+	if field == "" {
+		return nil, fmt.Errorf("condition %q: expected field at %d", p.src, start)
+	}
+
+	var op string
+	for _, tok := range []string{"<=", ">=", "!=", "<", ">", "="} {
+		if p.accept(tok) {
+			op = tok
+			break
+		}
+	}
+	if op == "" {
+		return nil, fmt.Errorf("condition %q: expected operator after %q", p.src, field)
+	}
+
+	p.skipSpace()
+	start = p.pos
+	if p.pos < len(p.src) && p.src[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.src) && unicode.IsDigit(rune(p.src[p.pos])) {
+		p.pos++
+	}
+	value, err := strconv.Atoi(p.src[start:p.pos])
+	if err != nil {
+		return nil, fmt.Errorf("condition %q: bad number at %d", p.src, start)
+	}
+	return Compare{field, op, value}, nil
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 )
 
@@ -12,35 +14,27 @@ type Pulse struct {
 	high     bool
 }
 
+// ModuleKind is the behaviour behind a module prefix. Receive reports the
+// level to send on, or ok=false when the module swallows the pulse.
+type ModuleKind interface {
+	Connect(input string)
+	Receive(from string, high bool) (out, ok bool)
+	Reset()
+	State() string
+}
+
 type Module struct {
 	name  string
-	typ   byte // '%', '&', 'b'
+	kind  ModuleKind
 	dests []string
-	state bool
-	mem   map[string]bool
 }
 
 func (m *Module) process(from string, high bool) []Pulse {
-	switch m.typ {
-	case '%': // flip-flop
-		if high {
-			return nil
-		}
-		m.state = !m.state
-		return m.send(m.state)
-	case '&': // conjunction
-		m.mem[from] = high
-		allHigh := true
-		for _, v := range m.mem {
-			if !v {
-				allHigh = false
-				break
-			}
-		}
-		return m.send(!allHigh)
-	default: // broadcast
-		return m.send(high)
+	out, ok := m.kind.Receive(from, high)
+	if !ok {
+		return nil
 	}
+	return m.send(out)
 }
 
 func (m *Module) send(high bool) []Pulse {
@@ -51,109 +45,123 @@ func (m *Module) send(high bool) []Pulse {
 	return pulses
 }
 
-func parse(lines []string) map[string]*Module {
-	modules := make(map[string]*Module)
+type flipFlop struct{ on bool }
 
-	for _, line := range lines {
-		parts := strings.Split(line, " -> ")
-		spec, dests := parts[0], strings.Split(parts[1], ", ")
-
-		var name string
-		var typ byte
-		if spec == "broadcaster" {
-			name, typ = "broadcaster", 'b'
-		} else {
-			name, typ = spec[1:], spec[0]
-		}
+func (f *flipFlop) Connect(string) {}
 
-		modules[name] = &Module{
-			name:  name,
-			typ:   typ,
-			dests: dests,
-			mem:   make(map[string]bool),
-		}
+func (f *flipFlop) Receive(_ string, high bool) (bool, bool) {
+	if high {
+		return false, false
 	}
+	f.on = !f.on
+	return f.on, true
+}
 
-	// Wire conjunction inputs
-	for name, mod := range modules {
-		for _, dest := range mod.dests {
-			if destMod := modules[dest]; destMod != nil && destMod.typ == '&' {
-				destMod.mem[name] = false
-			}
-		}
+func (f *flipFlop) Reset() { f.on = false }
+
+func (f *flipFlop) State() string {
+	if f.on {
+		return "1"
 	}
+	return "0"
+}
 
-	return modules
+type conjunction struct {
+	inputs []string
+	mem    map[string]bool
 }
 
-func gcd(a, b int64) int64 {
-	for b != 0 {
-		a, b = b, a%b
+func (c *conjunction) Connect(input string) {
+	if _, ok := c.mem[input]; ok {
+		return
 	}
-	return a
+	c.inputs = append(c.inputs, input)
+	sort.Strings(c.inputs)
+	c.mem[input] = false
 }
 
-func lcm(a, b int64) int64 { return a * b / gcd(a, b) }
+func (c *conjunction) Receive(from string, high bool) (bool, bool) {
+	c.mem[from] = high
+	for _, v := range c.mem {
+		if !v {
+			return true, true
+		}
+	}
+	return false, true
+}
 
-func simulate(modules map[string]*Module, presses int64, findRx bool) (int, int, int64) {
-	low, high := 0, 0
-	cycles := make(map[string]int64)
-	var rxInputs []string
-
-	if findRx {
-		for name, mod := range modules {
-			for _, dest := range mod.dests {
-				if dest == "rx" {
-					for iName, iMod := range modules {
-						for _, iDest := range iMod.dests {
-							if iDest == name {
-								rxInputs = append(rxInputs, iName)
-							}
-						}
-					}
-				}
-			}
+func (c *conjunction) Reset() {
+	for k := range c.mem {
+		c.mem[k] = false
+	}
+}
+
+func (c *conjunction) State() string {
+	var sb strings.Builder
+	for _, in := range c.inputs {
+		if c.mem[in] {
+			sb.WriteByte('1')
+		} else {
+			sb.WriteByte('0')
 		}
 	}
+	return sb.String()
+}
 
-	for press := int64(1); press <= presses; press++ {
-		queue := []Pulse{{"button", "broadcaster", false}}
+type broadcaster struct{}
 
-		for len(queue) > 0 {
-			p := queue[0]
-			queue = queue[1:]
+func (broadcaster) Connect(string)                           {}
+func (broadcaster) Receive(_ string, high bool) (bool, bool) { return high, true }
+func (broadcaster) Reset()                                   {}
+func (broadcaster) State() string                            { return "" }
 
-			if p.high {
-				high++
-			} else {
-				low++
-			}
+// moduleKinds maps a name prefix to a constructor. Lines without a
+// registered prefix are broadcasters named by the whole spec.
+var moduleKinds = map[byte]func() ModuleKind{
+	'%': func() ModuleKind { return &flipFlop{} },
+	'&': func() ModuleKind { return &conjunction{mem: make(map[string]bool)} },
+}
 
-			if findRx && p.high {
-				for _, input := range rxInputs {
-					if p.from == input && cycles[input] == 0 {
-						cycles[input] = press
-						if len(cycles) == len(rxInputs) {
-							result := cycles[rxInputs[0]]
-							for i := 1; i < len(rxInputs); i++ {
-								result = lcm(result, cycles[rxInputs[i]])
-							}
-							return low, high, result
-						}
-					}
-				}
-			}
+func RegisterModuleKind(prefix byte, newKind func() ModuleKind) {
+	moduleKinds[prefix] = newKind
+}
+
+func parse(lines []string) map[string]*Module {
+	modules := make(map[string]*Module)
 
-			if mod := modules[p.to]; mod != nil {
-				queue = append(queue, mod.process(p.from, p.high)...)
+	for _, line := range lines {
+		parts := strings.Split(line, " -> ")
+		spec, dests := parts[0], strings.Split(parts[1], ", ")
+
+		name, kind := spec, ModuleKind(broadcaster{})
+		if newKind, ok := moduleKinds[spec[0]]; ok {
+			name, kind = spec[1:], newKind()
+		}
+
+		modules[name] = &Module{
+			name:  name,
+			kind:  kind,
+			dests: dests,
+		}
+	}
+
+	// Wire module inputs
+	for name, mod := range modules {
+		for _, dest := range mod.dests {
+			if destMod := modules[dest]; destMod != nil {
+				destMod.kind.Connect(name)
 			}
 		}
 	}
 
-	return low, high, -1
+	return modules
 }
 
 func main() {
+	tracePath := flag.String("trace", "", "write part 1 pulses as JSONL to this file")
+	showCycles := flag.Bool("cycles", false, "print per-module state cycles")
+	flag.Parse()
+
 	file, _ := os.Open("input.txt")
 	defer file.Close()
 
@@ -165,21 +173,45 @@ func main() {
 		}
 	}
 
-	modules := parse(lines)
+	sim := NewSimulator(parse(lines))
 
 	// Part 1
-	low, high, _ := simulate(modules, 1000, false)
+	if *tracePath != "" {
+		f, err := os.Create(*tracePath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		sim.Trace = NewTraceRecorder(f)
+	}
+	low, high := 0, 0
+	for range 1000 {
+		l, h := sim.Press(nil)
+		low, high = low+l, high+h
+	}
 	fmt.Printf("Part 1: %d\n", low*high)
+	if sim.Trace != nil {
+		if err := sim.Trace.Flush(); err != nil {
+			fmt.Fprintln(os.Stderr, "trace:", err)
+		}
+		sim.Trace = nil
+	}
 
-	// Reset
-	for _, mod := range modules {
-		mod.state = false
-		for k := range mod.mem {
-			mod.mem[k] = false
+	if *showCycles {
+		cycles := sim.DetectCycles(1 << 15)
+		for _, name := range sim.names {
+			if c, ok := cycles[name]; ok {
+				fmt.Printf("  %-12s start %5d length %5d\n", name, c.Start, c.Length)
+			}
 		}
 	}
 
 	// Part 2
-	_, _, result := simulate(modules, 10000000, true)
+	result, err := sim.FirstPress("rx", false, 10000000)
+	if err != nil {
+		fmt.Println("Part 2:", err)
+		return
+	}
 	fmt.Printf("Part 2: %d\n", result)
 }
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+
+	"aoc/cycle"
+)
+
+// Simulator pushes the button on a module network, one press at a time.
+type Simulator struct {
+	modules map[string]*Module
+	names   []string
+	inputs  map[string][]string
+	Start   Pulse
+	Trace   *TraceRecorder
+	presses int
+	queue   []Pulse
+}
+
+func NewSimulator(modules map[string]*Module) *Simulator {
+	s := &Simulator{
+		modules: modules,
+		inputs:  make(map[string][]string),
+		Start:   Pulse{"button", "broadcaster", false},
+	}
+	for name, mod := range modules {
+		s.names = append(s.names, name)
+		for _, dest := range mod.dests {
+			s.inputs[dest] = append(s.inputs[dest], name)
+		}
+	}
+	sort.Strings(s.names)
+	for _, in := range s.inputs {
+		sort.Strings(in)
+	}
+	return s
+}
+
+func (s *Simulator) Reset() {
+	for _, mod := range s.modules {
+		mod.kind.Reset()
+	}
+	s.presses = 0
+}
+
+func (s *Simulator) Presses() int { return s.presses }
+
+// Press sends the start pulse and runs the network until it settles,
+// calling observe (if non-nil) for every pulse delivered.
+func (s *Simulator) Press(observe func(Pulse)) (low, high int) {
+	s.presses++
+	s.queue = append(s.queue[:0], s.Start)
+
+	for head := 0; head < len(s.queue); head++ {
+		p := s.queue[head]
+		if p.high {
+			high++
+		} else {
+			low++
+		}
+		if s.Trace != nil {
+			s.Trace.record(s.presses, head, p)
+		}
+		if observe != nil {
+			observe(p)
+		}
+		if mod := s.modules[p.to]; mod != nil {
+			s.queue = append(s.queue, mod.process(p.from, p.high)...)
+		}
+	}
+	return low, high
+}
+
+type traceRecord struct {
+	Press int    `json:"press"`
+	Seq   int    `json:"seq"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+	High  bool   `json:"high"`
+}
+
+// TraceRecorder writes every delivered pulse as one JSON object per line.
+// Records are buffered, so Flush must be called once tracing is done.
+type TraceRecorder struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+	err error
+}
+
+func NewTraceRecorder(w io.Writer) *TraceRecorder {
+	bw := bufio.NewWriter(w)
+	return &TraceRecorder{w: bw, enc: json.NewEncoder(bw)}
+}
+
+func (t *TraceRecorder) record(press, seq int, p Pulse) {
+	if t.err != nil {
+		return
+	}
+	t.err = t.enc.Encode(traceRecord{press, seq, p.from, p.to, p.high})
+}
+
+// Flush writes out any buffered records and returns the first error seen.
+func (t *TraceRecorder) Flush() error {
+	if t.err == nil {
+		t.err = t.w.Flush()
+	}
+	return t.err
+}
+
+func (t *TraceRecorder) Err() error { return t.err }
+
+// upstream lists name and every module that can send it a pulse, directly
+// or through others, sorted.
+func (s *Simulator) upstream(name string) []string {
+	seen := map[string]bool{name: true}
+	queue := []string{name}
+	for len(queue) > 0 {
+		for _, in := range s.inputs[queue[0]] {
+			if _, ok := s.modules[in]; ok && !seen[in] {
+				seen[in] = true
+				queue = append(queue, in)
+			}
+		}
+		queue = queue[1:]
+	}
+	out := make([]string, 0, len(seen))
+	for m := range seen {
+		out = append(out, m)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// DetectCycles resets the network, presses up to limit times and returns
+// the state cycle of every module whose upstream repeats in that window.
+// A module's own state does not determine its future, but together with
+// every module upstream of it, it does: pulses from elsewhere never reach
+// them, and the queue keeps their pulses in the same relative order. So the
+// upstream state after each press is a function of the state before it,
+// and its first repeat fixes the cycle.
+func (s *Simulator) DetectCycles(limit int) map[string]cycle.Cycle {
+	s.Reset()
+	closures := make(map[string][]string, len(s.modules))
+	ids := make(map[string]map[string]int, len(s.modules))
+	seqs := make(map[string][]int, len(s.modules))
+	for name := range s.modules {
+		closures[name] = s.upstream(name)
+		ids[name] = make(map[string]int)
+	}
+	states := make(map[string]string, len(s.modules))
+	var sb strings.Builder
+	recordStates := func() {
+		for name, mod := range s.modules {
+			states[name] = mod.kind.State()
+		}
+		for name, closure := range closures {
+			sb.Reset()
+			for _, m := range closure {
+				sb.WriteString(states[m])
+				sb.WriteByte(',')
+			}
+			id, ok := ids[name][sb.String()]
+			if !ok {
+				id = len(ids[name])
+				ids[name][sb.String()] = id
+			}
+			seqs[name] = append(seqs[name], id)
+		}
+	}
+
+	recordStates()
+	for s.presses < limit {
+		s.Press(nil)
+		recordStates()
+	}
+
+	cycles := make(map[string]cycle.Cycle)
+	for name, seq := range seqs {
+		next := func(press int) int { return press + 1 }
+		state := func(press int) int { return seq[press] }
+		if c, _, ok := cycle.DetectWithin(0, next, state, len(seq)-1); ok {
+			cycles[name] = c
+		}
+	}
+	return cycles
+}
+
+// probePresses is how long FirstPress simulates before trying to explain
+// the target through periodic feeders.
+const probePresses = 1 << 14
+
+// FirstPress returns the first press during which target receives a pulse
+// of the given level. It simulates directly, and when target is fed by a
+// single conjunction whose inputs fire on a strict period it combines
+// those periods with the CRT. The periodicity is checked, not assumed; if
+// it does not hold, simulation continues up to limit.
+func (s *Simulator) FirstPress(target string, high bool, limit int) (int64, error) {
+	s.Reset()
+
+	var feeder string
+	var conj *conjunction
+	if in := s.inputs[target]; len(in) == 1 && !high {
+		if c, ok := s.modules[in[0]].kind.(*conjunction); ok {
+			feeder, conj = in[0], c
+		}
+	}
+	fires := make(map[string][]int)
+
+	found := false
+	observe := func(p Pulse) {
+		if p.to == target && p.high == high {
+			found = true
+		}
+		if conj != nil && p.to == feeder && p.high {
+			if f := fires[p.from]; len(f) == 0 || f[len(f)-1] != s.presses {
+				fires[p.from] = append(f, s.presses)
+			}
+		}
+	}
+
+	probe := min(limit, probePresses)
+	for s.presses < probe {
+		if s.Press(observe); found {
+			return int64(s.presses), nil
+		}
+	}
+
+	if conj != nil {
+		if n, ok := periodicMeet(conj.inputs, fires); ok {
+			return n, nil
+		}
+	}
+
+	for s.presses < limit {
+		if s.Press(observe); found {
+			return int64(s.presses), nil
+		}
+	}
+	return -1, fmt.Errorf("%s never received a %s pulse within %d presses", target, level(high), limit)
+}
+
+func level(high bool) string {
+	if high {
+		return "high"
+	}
+	return "low"
+}
+
+// periodicMeet checks each input fired at offset+k*period for every k in
+// the probe window and returns the first press where all of them coincide.
+func periodicMeet(inputs []string, fires map[string][]int) (int64, bool) {
+	r, m := big.NewInt(0), big.NewInt(1)
+	latest := 0
+	for _, in := range inputs {
+		f := fires[in]
+		if len(f) < 2 {
+			return 0, false
+		}
+		period := f[1] - f[0]
+		for i := 2; i < len(f); i++ {
+			if f[i]-f[i-1] != period {
+				return 0, false
+			}
+		}
+		if f[0] > period {
+			// An offset larger than the period means an earlier firing was missed.
+			return 0, false
+		}
+		var ok bool
+		if r, m, ok = crt(r, m, big.NewInt(int64(f[0]%period)), big.NewInt(int64(period))); !ok {
+			return 0, false
+		}
+		latest = max(latest, f[0])
+	}
+
+	// Smallest solution at or after the latest first firing.
+	lo := big.NewInt(int64(latest))
+	if r.Cmp(lo) < 0 {
+		k := new(big.Int).Sub(lo, r)
+		k.Add(k, new(big.Int).Sub(m, big.NewInt(1)))
+		k.Quo(k, m)
+		r.Add(r, k.Mul(k, m))
+	}
+	if !r.IsInt64() {
+		return 0, false
+	}
+	return r.Int64(), true
+}
+
+// crt merges x ≡ r1 (mod m1) and x ≡ r2 (mod m2), allowing non-coprime moduli.
+func crt(r1, m1, r2, m2 *big.Int) (*big.Int, *big.Int, bool) {
+	g, p := new(big.Int), new(big.Int)
+	g.GCD(p, nil, m1, m2)
+
+	diff := new(big.Int).Sub(r2, r1)
+	if new(big.Int).Mod(diff, g).Sign() != 0 {
+		return nil, nil, false
+	}
+
+	lcm := new(big.Int).Mul(m1, new(big.Int).Quo(m2, g))
+	step := new(big.Int).Quo(diff, g)
+	step.Mul(step, p)
+	step.Mod(step, new(big.Int).Quo(m2, g))
+
+	x := new(big.Int).Mul(m1, step)
+	x.Add(x, r1)
+	x.Mod(x, lcm)
+	return x, lcm, true
+}
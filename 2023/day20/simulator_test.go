@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"strings"
+	"testing"
+)
+
+// The two example networks from the puzzle.
+var (
+	example1 = []string{
+		"broadcaster -> a, b, c",
+		"%a -> b",
+		"%b -> c",
+		"%c -> inv",
+		"&inv -> a",
+	}
+	example2 = []string{
+		"broadcaster -> a",
+		"%a -> inv, con",
+		"&inv -> b",
+		"%b -> con",
+		"&con -> output",
+	}
+)
+
+// counters builds a network like the real input: one flip-flop counter
+// per period, each resetting itself through a hub when it reaches its
+// period, and an inverter per hub feeding &fin -> rx. Counter k's inverter
+// sends fin a high pulse on every multiple of periods[k] and a low one
+// straight after, so rx first gets a low pulse at the lcm of the periods.
+func counters(periods ...int) []string {
+	var lines, starts []string
+	for k, period := range periods {
+		name := string(rune('a' + k))
+		bit := func(i int) string { return fmt.Sprintf("%s%d", name, i) }
+		hub := []string{bit(0)}
+		n := bits.Len(uint(period))
+		for i := range n {
+			var dests []string
+			if i+1 < n {
+				dests = append(dests, bit(i+1))
+			}
+			if period>>i&1 == 1 {
+				dests = append(dests, name+"h")
+			} else {
+				hub = append(hub, bit(i))
+			}
+			lines = append(lines, "%"+bit(i)+" -> "+strings.Join(dests, ", "))
+		}
+		lines = append(lines,
+			"&"+name+"h -> "+strings.Join(append(hub, name+"i"), ", "),
+			"&"+name+"i -> fin")
+		starts = append(starts, bit(0))
+	}
+	return append(lines,
+		"broadcaster -> "+strings.Join(starts, ", "),
+		"&fin -> rx")
+}
+
+func TestPart1(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		network []string
+		want    int
+	}{
+		{"example 1", example1, 32000000},
+		{"example 2", example2, 11687500},
+	} {
+		sim := NewSimulator(parse(tc.network))
+		low, high := 0, 0
+		for range 1000 {
+			l, h := sim.Press(nil)
+			low, high = low+l, high+h
+		}
+		if low*high != tc.want {
+			t.Errorf("%s: %d low × %d high = %d, want %d", tc.name, low, high, low*high, tc.want)
+		}
+	}
+}
+
+func TestTrace(t *testing.T) {
+	var buf bytes.Buffer
+	sim := NewSimulator(parse(example1))
+	sim.Trace = NewTraceRecorder(&buf)
+	low, high := sim.Press(nil)
+	if err := sim.Trace.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != low+high || len(lines) != 12 {
+		t.Fatalf("traced %d pulses, want 12", len(lines))
+	}
+	var first traceRecord
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if first != (traceRecord{Press: 1, Seq: 0, From: "button", To: "broadcaster", High: false}) {
+		t.Errorf("first record = %+v", first)
+	}
+}
+
+func TestFirstPress(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		network []string
+		target  string
+		high    bool
+		want    int64
+		// fast is set when the answer lies beyond the probe, so it must
+		// come from periodicMeet rather than simulation.
+		fast bool
+	}{
+		{"example 2 low", example2, "output", false, 1, false},
+		{"example 2 high", example2, "output", true, 1, false},
+		{"small counters", counters(3, 5), "rx", false, 15, false},
+		{"shared factor", counters(6, 9), "rx", false, 18, false},
+		{"counters past the probe", counters(127, 131), "rx", false, 127 * 131, true},
+	} {
+		sim := NewSimulator(parse(tc.network))
+		got, err := sim.FirstPress(tc.target, tc.high, 1_000_000)
+		if err != nil || got != tc.want {
+			t.Errorf("%s: FirstPress = %d, %v, want %d", tc.name, got, err, tc.want)
+		}
+		if tc.fast && sim.Presses() != probePresses {
+			t.Errorf("%s: simulated %d presses, want only the %d-press probe", tc.name, sim.Presses(), probePresses)
+		}
+	}
+
+	sim := NewSimulator(parse(example1))
+	if got, err := sim.FirstPress("output", false, 100); err == nil {
+		t.Errorf("FirstPress to a module that is never sent to = %d, want an error", got)
+	}
+}
+
+func TestPeriodicMeet(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		fires map[string][]int
+		want  int64
+		ok    bool
+	}{
+		{"coprime", map[string][]int{"x": {3, 6, 9}, "y": {5, 10, 15}}, 15, true},
+		{"offsets", map[string][]int{"x": {1, 5, 9}, "y": {2, 5, 8}}, 5, true},
+		{"shared factor", map[string][]int{"x": {4, 8, 12}, "y": {6, 12}}, 12, true},
+		{"not periodic", map[string][]int{"x": {3, 6, 9}, "y": {5, 10, 16}}, 0, false},
+		{"single firing", map[string][]int{"x": {3, 6, 9}, "y": {5}}, 0, false},
+		{"never fires", map[string][]int{"x": {3, 6, 9}}, 0, false},
+		{"missed first firing", map[string][]int{"x": {3, 6, 9}, "y": {9, 13, 17}}, 0, false},
+		{"never coincide", map[string][]int{"x": {2, 4, 6}, "y": {1, 3, 5}}, 0, false},
+	} {
+		got, ok := periodicMeet([]string{"x", "y"}, tc.fires)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("%s: periodicMeet = %d, %v, want %d, %v", tc.name, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestCRT(t *testing.T) {
+	for _, tc := range []struct {
+		r1, m1, r2, m2 int64
+		r, m           int64
+		ok             bool
+	}{
+		{2, 3, 3, 5, 8, 15, true},
+		{0, 1, 4, 7, 4, 7, true},
+		{1, 4, 3, 6, 9, 12, true},
+		{0, 2, 1, 4, 0, 0, false},
+	} {
+		r, m, ok := crt(big.NewInt(tc.r1), big.NewInt(tc.m1), big.NewInt(tc.r2), big.NewInt(tc.m2))
+		if ok != tc.ok || ok && (r.Int64() != tc.r || m.Int64() != tc.m) {
+			t.Errorf("crt(%d mod %d, %d mod %d) = %v mod %v, %v, want %d mod %d, %v",
+				tc.r1, tc.m1, tc.r2, tc.m2, r, m, ok, tc.r, tc.m, tc.ok)
+		}
+	}
+}
+
+func TestDetectCycles(t *testing.T) {
+	// want maps a module to its cycle start and length; none lists modules
+	// that must not repeat within limit.
+	for _, tc := range []struct {
+		name    string
+		network []string
+		limit   int
+		want    map[string][2]int
+		none    []string
+	}{
+		// a flips every press; b flips every other press, so everything
+		// that depends on b repeats every four.
+		{"example 2", example2, 20, map[string][2]int{
+			"broadcaster": {0, 1},
+			"a":           {0, 2},
+			"inv":         {0, 2},
+			"b":           {0, 4},
+			"con":         {0, 4},
+		}, nil},
+		// An inverter's memory of its hub starts low and is high after
+		// every press, so its cycle starts at 1. fin sits downstream of
+		// both counters, so its cycle is their lcm.
+		{"counters", counters(3, 5), 40, map[string][2]int{
+			"a0": {0, 3}, "ah": {0, 3}, "ai": {1, 3},
+			"b0": {0, 5}, "bh": {0, 5}, "bi": {1, 5},
+			"fin": {1, 15},
+		}, nil},
+		{"short limit", counters(3, 5), 10, map[string][2]int{
+			"a0": {0, 3}, "b0": {0, 5},
+		}, []string{"fin"}},
+	} {
+		cycles := NewSimulator(parse(tc.network)).DetectCycles(tc.limit)
+		for name, want := range tc.want {
+			if got := cycles[name]; got.Start != want[0] || got.Length != want[1] {
+				t.Errorf("%s: %s has cycle %+v, want start %d length %d", tc.name, name, got, want[0], want[1])
+			}
+		}
+		for _, name := range tc.none {
+			if got, ok := cycles[name]; ok {
+				t.Errorf("%s: %s has cycle %+v, want none within %d presses", tc.name, name, got, tc.limit)
+			}
+		}
+	}
+}
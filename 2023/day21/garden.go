@@ -0,0 +1,232 @@
+package main
+
+import "fmt"
+
+var dirs = []Point{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+
+// Garden is the map of plots. A plot is reachable in exactly n steps when
+// its shortest distance d satisfies d <= n and d ≡ n (mod 2), since a walker
+// can always waste two steps going back and forth.
+type Garden struct {
+	grid  [][]rune
+	h, w  int
+	start Point
+}
+
+func NewGarden(grid [][]rune, start Point) *Garden {
+	return &Garden{grid, len(grid), len(grid[0]), start}
+}
+
+// block holds BFS distances over the (2r+1)×(2r+1) tiles around the start
+// tile, -1 where a plot cannot be reached.
+type block struct {
+	r    int
+	dist [][]int
+}
+
+func (g *Garden) distances(r int) block {
+	n := 2*r + 1
+	b := block{r, make([][]int, n*g.h)}
+	for i := range b.dist {
+		b.dist[i] = make([]int, n*g.w)
+		for j := range b.dist[i] {
+			b.dist[i][j] = -1
+		}
+	}
+	s := Point{r*g.h + g.start.row, r*g.w + g.start.col}
+	b.dist[s.row][s.col] = 0
+	queue := []Point{s}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, d := range dirs {
+			q := Point{p.row + d.row, p.col + d.col}
+			if q.row < 0 || q.row >= n*g.h || q.col < 0 || q.col >= n*g.w ||
+				b.dist[q.row][q.col] >= 0 || g.grid[q.row%g.h][q.col%g.w] == '#' {
+				continue
+			}
+			b.dist[q.row][q.col] = b.dist[p.row][p.col] + 1
+			queue = append(queue, q)
+		}
+	}
+	return b
+}
+
+// at is the distance to plot (row, col) of tile (ti, tj), tile (0, 0)
+// being the start tile.
+func (g *Garden) at(b block, ti, tj, row, col int) int {
+	return b.dist[(ti+b.r)*g.h+row][(tj+b.r)*g.w+col]
+}
+
+func reachableIn(d, steps int) bool {
+	return d >= 0 && d <= steps && (steps-d)%2 == 0
+}
+
+// CountFinite counts the plots reachable in exactly steps without leaving
+// the map.
+func (g *Garden) CountFinite(steps int) int {
+	b := g.distances(0)
+	count := 0
+	for _, row := range b.dist {
+		for _, d := range row {
+			if reachableIn(d, steps) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// Infinite counts reachable plots on the map tiled forever. Beyond a ring
+// of k tiles around the start, the distance to a plot is assumed to grow by
+// exactly one tile height or width per tile further out. That is checked
+// rather than trusted: a BFS one ring wider must agree with the
+// extrapolation, and k grows until it does.
+type Infinite struct {
+	g *Garden
+	k int
+	b block
+}
+
+const maxRing = 8
+
+func (g *Garden) Infinite() (*Infinite, error) {
+	for k := 1; k <= maxRing; k++ {
+		b := g.distances(k + 1)
+		if g.stable(b, k) {
+			return &Infinite{g, k, b}, nil
+		}
+	}
+	return nil, fmt.Errorf("tile distances do not settle within %d tiles of the start", maxRing)
+}
+
+// stable reports whether every tile on ring k+1 matches the extrapolation
+// from ring k.
+func (g *Garden) stable(b block, k int) bool {
+	clamp := func(v int) int { return max(-k, min(k, v)) }
+	for ti := -k - 1; ti <= k+1; ti++ {
+		for tj := -k - 1; tj <= k+1; tj++ {
+			if max(ti, -ti, tj, -tj) != k+1 {
+				continue
+			}
+			ci, cj := clamp(ti), clamp(tj)
+			extra := (ti-ci)*sign(ti)*g.h + (tj-cj)*sign(tj)*g.w
+			for row := range g.h {
+				for col := range g.w {
+					want := g.at(b, ci, cj, row, col)
+					if want >= 0 {
+						want += extra
+					}
+					if g.at(b, ti, tj, row, col) != want {
+						return false
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+
+func sign(v int) int {
+	if v < 0 {
+		return -1
+	}
+	return 1
+}
+
+// stepsOfParity counts n in [1, limit] with n ≡ parity (mod 2) when step
+// is odd, or all of them when step is even and parity is already right.
+func stepsOfParity(limit, step, parity int) int {
+	if limit <= 0 {
+		return 0
+	}
+	if step%2 == 0 {
+		if parity == 0 {
+			return limit
+		}
+		return 0
+	}
+	if parity == 1 {
+		return (limit + 1) / 2
+	}
+	return limit / 2
+}
+
+// Count counts plots reachable in exactly steps. Tiles within the ring are
+// read off the BFS. A tile straight out from ring tile t is n tiles further
+// on, adding n·h (or n·w); a diagonal one adds a·h + b·w. Those are
+// counted per plot by arithmetic instead of by walking the tiles.
+func (inf *Infinite) Count(steps int) int {
+	g, k, b := inf.g, inf.k, inf.b
+
+	// Plots n ≥ 1 tiles beyond a ring tile along one axis.
+	axis := func(d, step int) int {
+		if d < 0 || d > steps {
+			return 0
+		}
+		return stepsOfParity((steps-d)/step, step, (steps-d)%2)
+	}
+	// Plots a, b ≥ 1 tiles beyond a ring corner on both axes. This depends
+	// only on d, and a tile holds few distinct distances, so memoise.
+	cornerMemo := make(map[int]int)
+	corner := func(d int) int {
+		if d < 0 || d > steps {
+			return 0
+		}
+		if n, ok := cornerMemo[d]; ok {
+			return n
+		}
+		n := 0
+		for a := 1; d+a*g.h+g.w <= steps; a++ {
+			rest := steps - d - a*g.h
+			n += stepsOfParity(rest/g.w, g.w, rest%2)
+		}
+		cornerMemo[d] = n
+		return n
+	}
+
+	count := 0
+	for row := range g.h {
+		for col := range g.w {
+			for ti := -k; ti <= k; ti++ {
+				for tj := -k; tj <= k; tj++ {
+					if reachableIn(g.at(b, ti, tj, row, col), steps) {
+						count++
+					}
+				}
+			}
+			for t := -k; t <= k; t++ {
+				count += axis(g.at(b, -k, t, row, col), g.h)
+				count += axis(g.at(b, k, t, row, col), g.h)
+				count += axis(g.at(b, t, -k, row, col), g.w)
+				count += axis(g.at(b, t, k, row, col), g.w)
+			}
+			for _, ti := range []int{-k, k} {
+				for _, tj := range []int{-k, k} {
+					count += corner(g.at(b, ti, tj, row, col))
+				}
+			}
+		}
+	}
+	return count
+}
+
+// QuadraticCheck tests the usual shortcut for this puzzle: that the count
+// at steps s0, s0+h, s0+2h, ... (s0 = steps mod h) is a quadratic in the
+// number of tiles crossed. It fits three exact counts, predicts a fourth
+// and the target, and reports whether the prediction holds.
+func (inf *Infinite) QuadraticCheck(steps int) (predicted int, holds bool) {
+	h := inf.g.h
+	s0 := steps % h
+	y := make([]int, 4)
+	for i := range y {
+		y[i] = inf.Count(s0 + i*h)
+	}
+	at := func(x int) int {
+		// Newton forward differences through x = 0, 1, 2.
+		d1, d2 := y[1]-y[0], y[2]-2*y[1]+y[0]
+		return y[0] + d1*x + d2*x*(x-1)/2
+	}
+	x := steps / h
+	return at(x), at(3) == y[3] && at(x) == inf.Count(steps)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const example = `...........
+.....###.#.
+.###.##..#.
+..#.#...#..
+....#.#....
+.##..S####.
+.##..#...#.
+.......##..
+.##.#.####.
+.##..##.##.
+...........`
+
+func parseGarden(input string) ([][]rune, Point) {
+	var grid [][]rune
+	var start Point
+	for r, line := range strings.Split(input, "\n") {
+		row := []rune(line)
+		for c, ch := range row {
+			if ch == 'S' {
+				start = Point{r, c}
+				row[c] = '.'
+			}
+		}
+		grid = append(grid, row)
+	}
+	return grid, start
+}
+
+// bruteForce counts reachable plots with a BFS over the infinite map.
+func bruteForce(g *Garden, steps int) int {
+	mod := func(a, m int) int { return (a%m + m) % m }
+	dist := map[Point]int{g.start: 0}
+	queue := []Point{g.start}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if dist[p] == steps {
+			continue
+		}
+		for _, d := range dirs {
+			q := Point{p.row + d.row, p.col + d.col}
+			if _, ok := dist[q]; ok || g.grid[mod(q.row, g.h)][mod(q.col, g.w)] == '#' {
+				continue
+			}
+			dist[q] = dist[p] + 1
+			queue = append(queue, q)
+		}
+	}
+	count := 0
+	for _, d := range dist {
+		if reachableIn(d, steps) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestExample(t *testing.T) {
+	grid, start := parseGarden(example)
+	g := NewGarden(grid, start)
+	if got := g.CountFinite(6); got != 16 {
+		t.Errorf("CountFinite(6) = %d, want 16", got)
+	}
+	inf, err := g.Infinite()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for steps, want := range map[int]int{6: 16, 10: 50, 50: 1594, 100: 6536} {
+		if got := inf.Count(steps); got != want {
+			t.Errorf("Count(%d) = %d, want %d", steps, got, want)
+		}
+	}
+}
+
+func TestCountMatchesBruteForce(t *testing.T) {
+	grid, _ := parseGarden(example)
+	starts := []Point{{0, 0}, {1, 10}, {7, 3}, {10, 10}, {4, 0}}
+	for _, start := range starts {
+		if grid[start.row][start.col] == '#' {
+			t.Fatalf("start %v is a rock", start)
+		}
+		g := NewGarden(grid, start)
+		inf, err := g.Infinite()
+		if err != nil {
+			t.Fatalf("start %v: %v", start, err)
+		}
+		for _, steps := range []int{0, 1, 7, 12, 33, 58, 81} {
+			if got, want := inf.Count(steps), bruteForce(g, steps); got != want {
+				t.Errorf("start %v: Count(%d) = %d, want %d", start, steps, got, want)
+			}
+		}
+	}
+}
+
+func TestCountNonSquare(t *testing.T) {
+	grid, start := parseGarden("..#....\n.#..S#.\n.......\n...#...")
+	g := NewGarden(grid, start)
+	inf, err := g.Infinite()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, steps := range []int{3, 10, 25, 40} {
+		if got, want := inf.Count(steps), bruteForce(g, steps); got != want {
+			t.Errorf("Count(%d) = %d, want %d", steps, got, want)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+type column [2]int
+
+// cell is the z-extent a brick occupies within one (x,y) column.
+type cell struct {
+	lo, hi, id int
+}
+
+// Stack holds settled bricks. Each (x,y) column keeps its occupied
+// z-intervals sorted, so landing heights and supporters are found with a
+// binary search per footprint cell instead of walking unit cubes.
+type Stack struct {
+	bricks  map[int]Brick
+	columns map[column][]cell
+}
+
+func (b Brick) maxZ() int {
+	return max(b.start.z, b.end.z)
+}
+
+func (b Brick) footprint() []column {
+	var cols []column
+	for x := min(b.start.x, b.end.x); x <= max(b.start.x, b.end.x); x++ {
+		for y := min(b.start.y, b.end.y); y <= max(b.start.y, b.end.y); y++ {
+			cols = append(cols, column{x, y})
+		}
+	}
+	return cols
+}
+
+func (b Brick) dropTo(z int) Brick {
+	drop := b.minZ() - z
+	b.start.z -= drop
+	b.end.z -= drop
+	return b
+}
+
+// NewStack lets every brick fall, lowest first, until it comes to rest.
+// It fails if two bricks start out overlapping.
+func NewStack(bricks []Brick) (*Stack, error) {
+	s := &Stack{
+		bricks:  make(map[int]Brick, len(bricks)),
+		columns: make(map[column][]cell),
+	}
+	sorted := append([]Brick(nil), bricks...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].minZ() < sorted[j].minZ()
+	})
+	for _, b := range sorted {
+		if _, err := s.Insert(b); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *Stack) Len() int { return len(s.bricks) }
+
+func (s *Stack) Brick(id int) Brick { return s.bricks[id] }
+
+// below returns the index of the first cell in col starting at or above z.
+func below(col []cell, z int) int {
+	return sort.Search(len(col), func(i int) bool { return col[i].lo >= z })
+}
+
+// overlapping returns a settled brick that shares a cube with b.
+func (s *Stack) overlapping(b Brick) (int, bool) {
+	for _, c := range b.footprint() {
+		col := s.columns[c]
+		i := below(col, b.minZ())
+		if i > 0 && col[i-1].hi >= b.minZ() {
+			return col[i-1].id, true
+		}
+		if i < len(col) && col[i].lo <= b.maxZ() {
+			return col[i].id, true
+		}
+	}
+	return 0, false
+}
+
+// restingZ is where b would land if dropped from its current height. Only
+// cells below b are consulted, so b must not overlap any brick already.
+func (s *Stack) restingZ(b Brick) int {
+	z := 1
+	for _, c := range b.footprint() {
+		col := s.columns[c]
+		if i := below(col, b.minZ()); i > 0 {
+			z = max(z, col[i-1].hi+1)
+		}
+	}
+	return z
+}
+
+func (s *Stack) attach(b Brick) {
+	s.bricks[b.id] = b
+	for _, c := range b.footprint() {
+		col := s.columns[c]
+		i := below(col, b.minZ())
+		col = append(col, cell{})
+		copy(col[i+1:], col[i:])
+		col[i] = cell{b.minZ(), b.maxZ(), b.id}
+		s.columns[c] = col
+	}
+}
+
+// detach lifts out a brick that must be in the stack.
+func (s *Stack) detach(id int) Brick {
+	b := s.bricks[id]
+	delete(s.bricks, id)
+	for _, c := range b.footprint() {
+		col := s.columns[c]
+		i := below(col, b.minZ())
+		s.columns[c] = append(col[:i], col[i+1:]...)
+	}
+	return b
+}
+
+// Insert drops a brick from its given position and returns where it lands.
+// Bricks already in the stack never move when one is added. A brick that
+// already overlaps a settled one, such as one placed in a gap shorter than
+// itself, is rejected. Otherwise falling cannot make it overlap anything:
+// it stops above the highest brick beneath it.
+func (s *Stack) Insert(b Brick) (Brick, error) {
+	if _, ok := s.bricks[b.id]; ok {
+		return Brick{}, fmt.Errorf("brick %d is already in the stack", b.id)
+	}
+	if other, ok := s.overlapping(b); ok {
+		return Brick{}, fmt.Errorf("brick %d overlaps brick %d", b.id, other)
+	}
+	b = b.dropTo(s.restingZ(b))
+	s.attach(b)
+	return b, nil
+}
+
+// Remove takes a brick out and lets everything above it re-settle. It
+// returns the ids of the bricks that moved, in the order they fell, and
+// fails without touching the stack if id is not in it.
+func (s *Stack) Remove(id int) ([]int, error) {
+	if _, ok := s.bricks[id]; !ok {
+		return nil, fmt.Errorf("brick %d is not in the stack", id)
+	}
+	removed := s.detach(id)
+
+	var above []Brick
+	for _, b := range s.bricks {
+		if b.minZ() > removed.minZ() {
+			above = append(above, b)
+		}
+	}
+	sort.Slice(above, func(i, j int) bool {
+		if above[i].minZ() != above[j].minZ() {
+			return above[i].minZ() < above[j].minZ()
+		}
+		return above[i].id < above[j].id
+	})
+
+	// Each brick is lifted out before it is dropped again, and everything
+	// it could land on has already settled, so Insert cannot collide.
+	var moved []int
+	for _, b := range above {
+		s.detach(b.id)
+		if landed, _ := s.Insert(b); landed.minZ() != b.minZ() {
+			moved = append(moved, b.id)
+		}
+	}
+	return moved, nil
+}
+
+// SupportedBy returns the bricks directly beneath id that hold it up. The
+// bool is false if id is not in the stack.
+func (s *Stack) SupportedBy(id int) ([]int, bool) {
+	b, ok := s.bricks[id]
+	if !ok {
+		return nil, false
+	}
+	seen := make(map[int]bool)
+	var out []int
+	for _, c := range b.footprint() {
+		col := s.columns[c]
+		if i := below(col, b.minZ()); i > 0 && col[i-1].hi == b.minZ()-1 {
+			if under := col[i-1].id; !seen[under] {
+				seen[under] = true
+				out = append(out, under)
+			}
+		}
+	}
+	sort.Ints(out)
+	return out, true
+}
+
+// DominatorTree answers "what falls if this brick goes" for every brick.
+// Brick Y falls when X is removed exactly when every chain of supports
+// from the ground to Y passes through X, i.e. X dominates Y.
+type DominatorTree struct {
+	idom map[int]int
+	size map[int]int
+}
+
+const ground = -1
+
+// Dominators builds the tree over the support DAG, visiting bricks by
+// height so every supporter is placed before the bricks it holds. Each
+// immediate dominator is the LCA of the supporters, found by binary
+// lifting, which keeps the whole build at O((n+m)·log n).
+func (s *Stack) Dominators() *DominatorTree {
+	order := make([]Brick, 0, len(s.bricks))
+	for _, b := range s.bricks {
+		order = append(order, b)
+	}
+	sort.Slice(order, func(i, j int) bool { return order[i].minZ() < order[j].minZ() })
+
+	n := len(order) + 1
+	levels := bits.Len(uint(n)) + 1
+	index := map[int]int{ground: 0}
+	ids := []int{ground}
+	depth := make([]int, n)
+	up := make([][]int, levels)
+	for k := range up {
+		up[k] = make([]int, n)
+	}
+
+	lca := func(a, b int) int {
+		if depth[a] < depth[b] {
+			a, b = b, a
+		}
+		for k := levels - 1; k >= 0; k-- {
+			if depth[a]-1<<k >= depth[b] {
+				a = up[k][a]
+			}
+		}
+		if a == b {
+			return a
+		}
+		for k := levels - 1; k >= 0; k-- {
+			if up[k][a] != up[k][b] {
+				a, b = up[k][a], up[k][b]
+			}
+		}
+		return up[0][a]
+	}
+
+	for _, b := range order {
+		v := len(ids)
+		index[b.id] = v
+		ids = append(ids, b.id)
+
+		dom := -1
+		supporters, _ := s.SupportedBy(b.id)
+		for _, under := range supporters {
+			if dom == -1 {
+				dom = index[under]
+			} else {
+				dom = lca(dom, index[under])
+			}
+		}
+		if dom == -1 {
+			dom = 0
+		}
+		depth[v] = depth[dom] + 1
+		up[0][v] = dom
+		for k := 1; k < levels; k++ {
+			up[k][v] = up[k-1][up[k-1][v]]
+		}
+	}
+
+	t := &DominatorTree{idom: make(map[int]int, n), size: make(map[int]int, n)}
+	sizes := make([]int, n)
+	for v := n - 1; v > 0; v-- {
+		sizes[v]++
+		sizes[up[0][v]] += sizes[v]
+		t.idom[ids[v]] = ids[up[0][v]]
+		t.size[ids[v]] = sizes[v]
+	}
+	return t
+}
+
+// Idom returns the brick whose removal is the last one id depends on, or
+// ground when no single brick holds it up.
+func (t *DominatorTree) Idom(id int) int { return t.idom[id] }
+
+// Falls counts the other bricks that fall when id is removed.
+func (t *DominatorTree) Falls(id int) int { return t.size[id] - 1 }
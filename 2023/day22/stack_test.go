@@ -0,0 +1,169 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const example = `1,0,1~1,2,1
+0,0,2~2,0,2
+0,2,3~2,2,3
+0,0,4~0,2,4
+2,0,5~2,2,5
+0,1,6~2,1,6
+1,1,8~1,1,9`
+
+func parseBricks(t *testing.T, input string) []Brick {
+	t.Helper()
+	var bricks []Brick
+	for i, line := range strings.Split(input, "\n") {
+		bricks = append(bricks, parseBrick(line, i))
+	}
+	return bricks
+}
+
+func exampleStack(t *testing.T) *Stack {
+	t.Helper()
+	s, err := NewStack(parseBricks(t, example))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSettle(t *testing.T) {
+	s := exampleStack(t)
+	want := []int{1, 2, 2, 3, 3, 4, 5}
+	for id, z := range want {
+		if got := s.Brick(id).minZ(); got != z {
+			t.Errorf("brick %d rests at z=%d, want %d", id, got, z)
+		}
+	}
+}
+
+func TestDominators(t *testing.T) {
+	s := exampleStack(t)
+	dom := s.Dominators()
+	safe, total := 0, 0
+	for id := range s.Len() {
+		if dom.Falls(id) == 0 {
+			safe++
+		}
+		total += dom.Falls(id)
+	}
+	if safe != 5 || total != 7 {
+		t.Errorf("safe = %d, falls = %d, want 5 and 7", safe, total)
+	}
+	if got := dom.Falls(0); got != 6 {
+		t.Errorf("Falls(A) = %d, want 6", got)
+	}
+	if got := dom.Falls(5); got != 1 {
+		t.Errorf("Falls(F) = %d, want 1", got)
+	}
+	if got := dom.Idom(6); got != 5 {
+		t.Errorf("Idom(G) = %d, want F (5)", got)
+	}
+	if got := dom.Idom(3); got != 0 {
+		t.Errorf("Idom(D) = %d, want A (0)", got)
+	}
+}
+
+func TestRemoveResettles(t *testing.T) {
+	s := exampleStack(t)
+	if moved, err := s.Remove(1); err != nil || len(moved) != 0 {
+		t.Errorf("removing B moved %v, %v, want nothing", moved, err)
+	}
+
+	s = exampleStack(t)
+	moved, err := s.Remove(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(moved)
+	if !slices.Equal(moved, []int{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("removing A moved %v, want every other brick", moved)
+	}
+	if s.Len() != 6 {
+		t.Errorf("Len = %d, want 6", s.Len())
+	}
+	for id, z := range map[int]int{1: 1, 2: 1, 3: 2, 4: 2, 5: 3, 6: 4} {
+		if got := s.Brick(id).minZ(); got != z {
+			t.Errorf("brick %d re-settled at z=%d, want %d", id, got, z)
+		}
+	}
+}
+
+func TestUnknownBrick(t *testing.T) {
+	s := exampleStack(t)
+	if _, err := s.Remove(42); err == nil {
+		t.Error("Remove(42) succeeded on a stack without brick 42")
+	}
+	if _, ok := s.SupportedBy(42); ok {
+		t.Error("SupportedBy(42) reported supporters for a missing brick")
+	}
+	// The failed Remove must leave the stack as it was.
+	if s.Len() != 7 {
+		t.Errorf("Len = %d, want 7", s.Len())
+	}
+	if got := s.Dominators().Falls(0); got != 6 {
+		t.Errorf("Falls(A) = %d after a failed Remove, want 6", got)
+	}
+	if under, ok := s.SupportedBy(6); !ok || !slices.Equal(under, []int{5}) {
+		t.Errorf("SupportedBy(G) = %v, %v, want [5]", under, ok)
+	}
+}
+
+func TestInsert(t *testing.T) {
+	// The bridge rests on the pillar, leaving column 0,0 empty at z=2..3.
+	s, err := NewStack(parseBricks(t, "0,0,1~0,0,1\n1,0,1~1,0,3\n0,0,4~1,0,4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A two-high brick fits the gap.
+	landed, err := s.Insert(parseBrick("0,0,2~0,0,3", 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if landed.minZ() != 2 {
+		t.Errorf("brick landed at z=%d, want 2", landed.minZ())
+	}
+
+	// A brick dropped from above stops on the highest one below it.
+	landed, err = s.Insert(parseBrick("0,0,9~1,0,9", 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if landed.minZ() != 5 {
+		t.Errorf("brick landed at z=%d, want 5", landed.minZ())
+	}
+
+	if _, err := s.Insert(parseBrick("0,0,20~0,0,20", 4)); err == nil {
+		t.Error("inserting a duplicate id succeeded")
+	}
+}
+
+func TestInsertCollision(t *testing.T) {
+	// Column 0,0 holds bricks at z=1 and z=3 with a one-high gap between.
+	s, err := NewStack(parseBricks(t, "0,0,1~0,0,1\n1,0,1~1,0,2\n0,0,3~1,0,3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"0,0,2~0,0,4", // taller than the gap it starts in
+		"0,0,3~0,0,3", // on top of a settled brick
+		"0,0,0~0,0,1", // reaching into one from below
+	} {
+		if _, err := s.Insert(parseBrick(line, 9)); err == nil {
+			t.Errorf("Insert(%s) succeeded, want a collision", line)
+		}
+	}
+	if s.Len() != 3 {
+		t.Errorf("Len = %d after rejected inserts, want 3", s.Len())
+	}
+
+	if _, err := NewStack(parseBricks(t, "0,0,1~2,0,1\n1,0,1~1,2,1")); err == nil {
+		t.Error("NewStack accepted overlapping bricks")
+	}
+}
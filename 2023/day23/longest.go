@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+var directions = []Point{{0, 1}, {0, -1}, {1, 0}, {-1, 0}}
+
+var slopeDir = map[byte]Point{
+	'^': {0, -1}, 'v': {0, 1}, '<': {-1, 0}, '>': {1, 0},
+}
+
+// Edge is a corridor between two junctions; cells lists every tile after
+// the starting junction, ending on the target junction.
+type Edge struct {
+	to    int
+	dist  int
+	cells []Point
+}
+
+// Graph is the maze compressed to its junctions. Edges are directed: an
+// undirected corridor appears once in each direction, a slope-blocked one
+// only in the direction the slopes allow.
+type Graph struct {
+	pos   []Point
+	index map[Point]int
+	edges [][]Edge
+}
+
+// BuildGraph compresses grid into junctions, including start and end.
+// With slopes set, a corridor is only usable in its downhill direction.
+// Junctions are tracked in a 64-bit mask, so at most 64 are supported.
+func BuildGraph(grid []string, start, end Point, slopes bool) (*Graph, error) {
+	rows, cols := len(grid), len(grid[0])
+	open := func(p Point) bool {
+		return p.x >= 0 && p.x < cols && p.y >= 0 && p.y < rows && grid[p.y][p.x] != '#'
+	}
+
+	g := &Graph{index: make(map[Point]int)}
+	addJunction := func(p Point) {
+		if _, ok := g.index[p]; !ok {
+			g.index[p] = len(g.pos)
+			g.pos = append(g.pos, p)
+		}
+	}
+	addJunction(start)
+	for y := range rows {
+		for x := range cols {
+			p := Point{x, y}
+			if !open(p) {
+				continue
+			}
+			neighbors := 0
+			for _, d := range directions {
+				if open(Point{x + d.x, y + d.y}) {
+					neighbors++
+				}
+			}
+			if neighbors > 2 {
+				addJunction(p)
+			}
+		}
+	}
+	addJunction(end)
+	if len(g.pos) > 64 {
+		return nil, fmt.Errorf("%d junctions, bitmask search supports at most 64", len(g.pos))
+	}
+
+	// canStep reports whether a move in direction d may leave p.
+	canStep := func(p, d Point) bool {
+		if !slopes {
+			return true
+		}
+		s, ok := slopeDir[grid[p.y][p.x]]
+		return !ok || s == d
+	}
+
+	g.edges = make([][]Edge, len(g.pos))
+	for from, jp := range g.pos {
+		for _, d := range directions {
+			prev, cur := jp, Point{jp.x + d.x, jp.y + d.y}
+			if !open(cur) || !canStep(prev, d) {
+				continue
+			}
+			cells := []Point{cur}
+			for {
+				if to, ok := g.index[cur]; ok {
+					g.edges[from] = append(g.edges[from], Edge{to, len(cells), cells})
+					break
+				}
+				moved := false
+				for _, nd := range directions {
+					next := Point{cur.x + nd.x, cur.y + nd.y}
+					if next == prev || !open(next) {
+						continue
+					}
+					if canStep(cur, nd) {
+						prev, cur = cur, next
+						cells = append(cells, cur)
+						moved = true
+					}
+					break
+				}
+				if !moved {
+					break
+				}
+			}
+		}
+	}
+	return g, nil
+}
+
+func (g *Graph) Index(p Point) int { return g.index[p] }
+
+// Result is a longest path as the junctions it visits, in order.
+type Result struct {
+	Length int
+	Path   []int
+}
+
+func (r Result) String() string { return strconv.Itoa(r.Length) }
+
+type searchState struct {
+	node    int
+	seen    uint64
+	dist    int
+	path    []int
+	calls   int
+	stopped bool
+}
+
+// Longest finds the longest simple path from one junction to another.
+// The search prunes any branch whose distance plus the best possible entry
+// into every unvisited junction cannot beat the best found so far, and the
+// first few levels are expanded up front so workers can share the rest.
+// It returns Length -1 when to is unreachable. If ctx ends first, the best
+// path found so far is returned with ctx.Err().
+func (g *Graph) Longest(ctx context.Context, from, to, workers int) (Result, error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	n := len(g.pos)
+	maxIn := make([]int, n)
+	var preds []int
+	for u, es := range g.edges {
+		for _, e := range es {
+			maxIn[e.to] = max(maxIn[e.to], e.dist)
+			if e.to == to {
+				preds = append(preds, u)
+			}
+		}
+	}
+	total := 0
+	for v, w := range maxIn {
+		if v != from {
+			total += w
+		}
+	}
+	// If the target has a single way in, reaching that junction commits
+	// the path to finishing there.
+	gate := -1
+	if len(preds) == 1 {
+		gate = preds[0]
+	}
+
+	var best atomic.Int64
+	best.Store(-1)
+
+	var dfs func(s *searchState, remaining int, local *Result)
+	dfs = func(s *searchState, remaining int, local *Result) {
+		// Once the poll sees ctx end, stopped unwinds every frame above it
+		// rather than letting them carry on with their other edges.
+		if s.calls++; s.stopped || s.calls%4096 == 0 && ctx.Err() != nil {
+			s.stopped = true
+			return
+		}
+		if s.node == to {
+			if s.dist > local.Length {
+				local.Length = s.dist
+				local.Path = append([]int(nil), s.path...)
+			}
+			for cur := best.Load(); int64(s.dist) > cur; cur = best.Load() {
+				if best.CompareAndSwap(cur, int64(s.dist)) {
+					break
+				}
+			}
+			return
+		}
+		if int64(s.dist+remaining) <= best.Load() {
+			return
+		}
+		node, dist := s.node, s.dist
+		for _, e := range g.edges[node] {
+			if s.seen&(1<<e.to) != 0 || (node == gate && e.to != to) {
+				continue
+			}
+			s.node, s.dist = e.to, dist+e.dist
+			s.seen |= 1 << e.to
+			s.path = append(s.path, e.to)
+			dfs(s, remaining-maxIn[e.to], local)
+			s.path = s.path[:len(s.path)-1]
+			s.seen &^= 1 << e.to
+			if s.stopped {
+				break
+			}
+		}
+		s.node, s.dist = node, dist
+	}
+
+	// Breadth-first expansion of the first levels into independent tasks.
+	type task struct {
+		state     searchState
+		remaining int
+	}
+	tasks := []task{{searchState{node: from, seen: 1 << from, path: []int{from}}, total}}
+	finished := Result{Length: -1}
+	for len(tasks) < workers*8 {
+		var next []task
+		grew := false
+		for _, t := range tasks {
+			if t.state.node == to {
+				if t.state.dist > finished.Length {
+					finished = Result{t.state.dist, t.state.path}
+				}
+				continue
+			}
+			for _, e := range g.edges[t.state.node] {
+				if t.state.seen&(1<<e.to) != 0 || (t.state.node == gate && e.to != to) {
+					continue
+				}
+				path := append(append([]int(nil), t.state.path...), e.to)
+				next = append(next, task{
+					searchState{node: e.to, seen: t.state.seen | 1<<e.to, dist: t.state.dist + e.dist, path: path},
+					t.remaining - maxIn[e.to],
+				})
+				grew = true
+			}
+		}
+		tasks = next
+		if !grew {
+			break
+		}
+	}
+	if finished.Length >= 0 {
+		best.Store(int64(finished.Length))
+	}
+
+	results := make([]Result, len(tasks))
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				results[i] = Result{Length: -1}
+				if ctx.Err() != nil {
+					continue
+				}
+				s := tasks[i].state
+				s.path = append([]int(nil), s.path...)
+				dfs(&s, tasks[i].remaining, &results[i])
+			}
+		}()
+	}
+	for i := range tasks {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	for _, r := range results {
+		if r.Length > finished.Length {
+			finished = r
+		}
+	}
+	return finished, ctx.Err()
+}
+
+// Render draws the path over the grid with 'O', like the puzzle text.
+func (g *Graph) Render(grid []string, path []int) []string {
+	out := make([][]byte, len(grid))
+	for y, row := range grid {
+		out[y] = []byte(row)
+	}
+	if len(path) > 0 {
+		p := g.pos[path[0]]
+		out[p.y][p.x] = 'S'
+	}
+	for i := 1; i < len(path); i++ {
+		var used *Edge
+		for j, e := range g.edges[path[i-1]] {
+			if e.to == path[i] && (used == nil || e.dist > used.dist) {
+				used = &g.edges[path[i-1]][j]
+			}
+		}
+		for _, c := range used.cells {
+			out[c.y][c.x] = 'O'
+		}
+	}
+	lines := make([]string, len(out))
+	for y, row := range out {
+		lines[y] = string(row)
+	}
+	return lines
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const example = `#.#####################
+#.......#########...###
+#######.#########.#.###
+###.....#.>.>.###.#.###
+###v#####.#v#.###.#.###
+###.>...#.#.#.....#...#
+###v###.#.#.#########.#
+###...#.#.#.......#...#
+#####.#.#.#######.#.###
+#.....#.#.#.......#...#
+#.#####.#.#.#########v#
+#.#...#...#...###...>.#
+#.#.#v#######v###.###v#
+#...#.>.#...>.>.#.###.#
+#####v#.#.###v#.#.###.#
+#.....#...#...#.#.#...#
+#.#########.###.#.#.###
+#...###...#...#...#.###
+###.###.#.###v#####v###
+#...#...#.#.>.>.#.>.###
+#.###.###.#.###.#.#v###
+#.....###...###...#...#
+#####################.#`
+
+func buildGraph(t *testing.T, grid []string, slopes bool) (*Graph, int, int) {
+	t.Helper()
+	start := Point{strings.IndexByte(grid[0], '.'), 0}
+	end := Point{strings.IndexByte(grid[len(grid)-1], '.'), len(grid) - 1}
+	g, err := BuildGraph(grid, start, end, slopes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g, g.Index(start), g.Index(end)
+}
+
+func TestLongest(t *testing.T) {
+	grid := strings.Split(example, "\n")
+	for _, tc := range []struct {
+		slopes bool
+		want   int
+	}{
+		{true, 94},
+		{false, 154},
+	} {
+		g, from, to := buildGraph(t, grid, tc.slopes)
+		for _, workers := range []int{1, 4} {
+			r, err := g.Longest(context.Background(), from, to, workers)
+			if err != nil || r.Length != tc.want {
+				t.Errorf("slopes=%v workers=%d: Longest = %d, %v, want %d", tc.slopes, workers, r.Length, err, tc.want)
+			}
+		}
+	}
+}
+
+// lattice is an n×n grid of crossroads joined by corridors, open at the
+// top-left and bottom-right. Its longest path takes far too long to find,
+// so a search over it only ends when it is cancelled.
+func lattice(n int) []string {
+	size := 2*n + 1
+	rows := make([][]byte, size)
+	for y := range rows {
+		rows[y] = []byte(strings.Repeat("#", size))
+		for x := 1; x < size-1; x++ {
+			if y%2 == 1 || x%2 == 1 && y > 0 && y < size-1 {
+				rows[y][x] = '.'
+			}
+		}
+	}
+	rows[0][1] = '.'
+	rows[size-1][size-2] = '.'
+	grid := make([]string, size)
+	for y, row := range rows {
+		grid[y] = string(row)
+	}
+	return grid
+}
+
+func TestLongestCancelled(t *testing.T) {
+	g, from, to := buildGraph(t, lattice(7), false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if _, err := g.Longest(ctx, from, to, 2); !errors.Is(err, context.Canceled) {
+		t.Errorf("Longest on a cancelled context returned %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Longest on a cancelled context took %s", elapsed)
+	}
+
+	// A deadline that passes mid-search must stop every frame of the
+	// search, not just the one that notices.
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	r, err := g.Longest(ctx, from, to, 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Longest past its deadline returned %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Longest ran %s past a 50ms deadline", elapsed)
+	}
+	if r.Length > 0 && (r.Path[0] != from || r.Path[len(r.Path)-1] != to) {
+		t.Errorf("partial path %v does not run from %d to %d", r.Path, from, to)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"aoc/linalg"
+)
+
+// Rock is a throw that hits every hailstone: position and velocity.
+type Rock struct {
+	pos, vel [3]int64
+}
+
+// rockSystem is the linear system for a rock (P, V) through three stones.
+// For each stone, (P - p)×(V - v) = 0; subtracting the equation of h0
+// from those of h1 and h2 cancels the P×V term and leaves six linear
+// equations in P and V.
+func rockSystem(h0, h1, h2 HailStone) (linalg.RatMatrix, []*big.Rat) {
+	var A linalg.RatMatrix
+	var b []*big.Rat
+	r := func(v int64) *big.Rat { return new(big.Rat).SetInt64(v) }
+	// cross(p, v)[axis] as an exact integer.
+	cross := func(p, v [3]int64, axis int) *big.Int {
+		i, j := (axis+1)%3, (axis+2)%3
+		a := new(big.Int).Mul(big.NewInt(p[i]), big.NewInt(v[j]))
+		return a.Sub(a, new(big.Int).Mul(big.NewInt(p[j]), big.NewInt(v[i])))
+	}
+	for _, h := range []HailStone{h1, h2} {
+		p0, v0, p, v := h0.ipos, h0.ivel, h.ipos, h.ivel
+		for axis := range 3 {
+			i, j := (axis+1)%3, (axis+2)%3
+			row := make([]*big.Rat, 6)
+			for k := range row {
+				row[k] = new(big.Rat)
+			}
+			// Coefficients of P, then of V.
+			row[i] = r(v0[j] - v[j])
+			row[j] = r(v[i] - v0[i])
+			row[3+i] = r(p[j] - p0[j])
+			row[3+j] = r(p0[i] - p[i])
+			rhs := new(big.Int).Sub(cross(p0, v0, axis), cross(p, v, axis))
+			A = append(A, row)
+			b = append(b, new(big.Rat).SetInt(rhs))
+		}
+	}
+	return A, b
+}
+
+// hitTime returns the time at which rock meets h, which must be the same
+// non-negative integer on every axis.
+func (rock Rock) hitTime(h HailStone) (int64, bool) {
+	t, known := int64(0), false
+	for axis := range 3 {
+		dp := h.ipos[axis] - rock.pos[axis]
+		dv := rock.vel[axis] - h.ivel[axis]
+		if dv == 0 {
+			if dp != 0 {
+				return 0, false
+			}
+			continue
+		}
+		if dp%dv != 0 || dp/dv < 0 {
+			return 0, false
+		}
+		if known && dp/dv != t {
+			return 0, false
+		}
+		t, known = dp/dv, true
+	}
+	return t, true
+}
+
+// throwRock solves for the rock exactly, using the first triple of
+// hailstones that gives a non-singular system, then checks that the rock
+// hits every hailstone at a non-negative integer time.
+func throwRock(hailstones []HailStone) (Rock, error) {
+	n := len(hailstones)
+	for i := range n {
+		for j := i + 1; j < n; j++ {
+			for k := j + 1; k < n; k++ {
+				A, b := rockSystem(hailstones[i], hailstones[j], hailstones[k])
+				x, err := linalg.SolveRat(A, b)
+				if err != nil {
+					continue
+				}
+				var rock Rock
+				for c := range 6 {
+					if !x[c].IsInt() || !x[c].Num().IsInt64() {
+						return Rock{}, fmt.Errorf("hailstones %d,%d,%d give a non-integer rock: %s", i, j, k, x[c].RatString())
+					}
+					if c < 3 {
+						rock.pos[c] = x[c].Num().Int64()
+					} else {
+						rock.vel[c-3] = x[c].Num().Int64()
+					}
+				}
+				for idx, h := range hailstones {
+					if _, ok := rock.hitTime(h); !ok {
+						return Rock{}, fmt.Errorf("rock %v from hailstones %d,%d,%d misses hailstone %d", rock, i, j, k, idx)
+					}
+				}
+				return rock, nil
+			}
+		}
+	}
+	return Rock{}, errors.New("every triple of hailstones gives a singular system")
+}
@@ -12,8 +12,11 @@ type Vec3 struct {
 	x, y, z float64
 }
 
+// HailStone keeps its exact integer coordinates alongside the float ones
+// used for the 2D intersection test.
 type HailStone struct {
-	pos, vel Vec3
+	pos, vel   Vec3
+	ipos, ivel [3]int64
 }
 
 func parseInput(filename string) []HailStone {
@@ -33,17 +36,14 @@ func parseInput(filename string) []HailStone {
 		posStr := strings.Split(parts[0], ",")
 		velStr := strings.Split(parts[1], ",")
 
-		px, _ := strconv.ParseFloat(strings.TrimSpace(posStr[0]), 64)
-		py, _ := strconv.ParseFloat(strings.TrimSpace(posStr[1]), 64)
-		pz, _ := strconv.ParseFloat(strings.TrimSpace(posStr[2]), 64)
-		vx, _ := strconv.ParseFloat(strings.TrimSpace(velStr[0]), 64)
-		vy, _ := strconv.ParseFloat(strings.TrimSpace(velStr[1]), 64)
-		vz, _ := strconv.ParseFloat(strings.TrimSpace(velStr[2]), 64)
-
-		hailstones = append(hailstones, HailStone{
-			pos: Vec3{px, py, pz},
-			vel: Vec3{vx, vy, vz},
-		})
+		var h HailStone
+		for axis := range 3 {
+			h.ipos[axis], _ = strconv.ParseInt(strings.TrimSpace(posStr[axis]), 10, 64)
+			h.ivel[axis], _ = strconv.ParseInt(strings.TrimSpace(velStr[axis]), 10, 64)
+		}
+		h.pos = Vec3{float64(h.ipos[0]), float64(h.ipos[1]), float64(h.ipos[2])}
+		h.vel = Vec3{float64(h.ivel[0]), float64(h.ivel[1]), float64(h.ivel[2])}
+		hailstones = append(hailstones, h)
 	}
 	return hailstones
 }
@@ -82,71 +82,21 @@ func part1(hailstones []HailStone, min, max float64) int {
 	return count
 }
 
-func abs(x float64) float64 {
-	if x < 0 {
-		return -x
+func part2(hailstones []HailStone) (int64, error) {
+	rock, err := throwRock(hailstones)
+	if err != nil {
+		return 0, err
 	}
-	return x
-}
-
-func solveSystem(A [][]float64, b []float64) []float64 {
-	n := len(b)
-	for i := range n {
-		A[i] = append(A[i], b[i])
-	}
-
-	for col := range n {
-		pivot := col
-		for row := col + 1; row < n; row++ {
-			if abs(A[row][col]) > abs(A[pivot][col]) {
-				pivot = row
-			}
-		}
-		A[col], A[pivot] = A[pivot], A[col]
-
-		for row := col + 1; row < n; row++ {
-			factor := A[row][col] / A[col][col]
-			for j := col; j <= n; j++ {
-				A[row][j] -= factor * A[col][j]
-			}
-		}
-	}
-
-	x := make([]float64, n)
-	for i := n - 1; i >= 0; i-- {
-		x[i] = A[i][n]
-		for j := i + 1; j < n; j++ {
-			x[i] -= A[i][j] * x[j]
-		}
-		x[i] /= A[i][i]
-	}
-	return x
-}
-
-func part2(hailstones []HailStone) int64 {
-	h0, h1, h2 := hailstones[0], hailstones[1], hailstones[2]
-	A := [][]float64{
-		{0, h0.vel.z - h1.vel.z, h1.vel.y - h0.vel.y, 0, h1.pos.z - h0.pos.z, h0.pos.y - h1.pos.y},
-		{h1.vel.z - h0.vel.z, 0, h0.vel.x - h1.vel.x, h0.pos.z - h1.pos.z, 0, h1.pos.x - h0.pos.x},
-		{h0.vel.y - h1.vel.y, h1.vel.x - h0.vel.x, 0, h1.pos.y - h0.pos.y, h0.pos.x - h1.pos.x, 0},
-		{0, h0.vel.z - h2.vel.z, h2.vel.y - h0.vel.y, 0, h2.pos.z - h0.pos.z, h0.pos.y - h2.pos.y},
-		{h2.vel.z - h0.vel.z, 0, h0.vel.x - h2.vel.x, h0.pos.z - h2.pos.z, 0, h2.pos.x - h0.pos.x},
-		{h0.vel.y - h2.vel.y, h2.vel.x - h0.vel.x, 0, h2.pos.y - h0.pos.y, h0.pos.x - h2.pos.x, 0},
-	}
-	b := []float64{
-		h0.pos.y*h0.vel.z - h0.vel.y*h0.pos.z - (h1.pos.y*h1.vel.z - h1.vel.y*h1.pos.z),
-		h0.pos.z*h0.vel.x - h0.vel.z*h0.pos.x - (h1.pos.z*h1.vel.x - h1.vel.z*h1.pos.x),
-		h0.pos.x*h0.vel.y - h0.vel.x*h0.pos.y - (h1.pos.x*h1.vel.y - h1.vel.x*h1.pos.y),
-		h0.pos.y*h0.vel.z - h0.vel.y*h0.pos.z - (h2.pos.y*h2.vel.z - h2.vel.y*h2.pos.z),
-		h0.pos.z*h0.vel.x - h0.vel.z*h0.pos.x - (h2.pos.z*h2.vel.x - h2.vel.z*h2.pos.x),
-		h0.pos.x*h0.vel.y - h0.vel.x*h0.pos.y - (h2.pos.x*h2.vel.y - h2.vel.x*h2.pos.y),
-	}
-	solution := solveSystem(A, b)
-	return int64(solution[0] + solution[1] + solution[2] + 0.5)
+	return rock.pos[0] + rock.pos[1] + rock.pos[2], nil
 }
 
 func main() {
 	hailstones := parseInput("input.txt")
 	fmt.Printf("Part 1: %d\n", part1(hailstones, 200000000000000, 400000000000000))
-	fmt.Printf("Part 2: %d\n", part2(hailstones))
+	result, err := part2(hailstones)
+	if err != nil {
+		fmt.Println("Part 2:", err)
+		return
+	}
+	fmt.Printf("Part 2: %d\n", result)
 }
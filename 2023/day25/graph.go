@@ -0,0 +1,301 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Graph is an undirected weighted graph over named nodes, stored as
+// per-node adjacency maps so memory grows with the edge count.
+type Graph struct {
+	names []string
+	index map[string]int
+	adj   []map[int]int
+}
+
+func NewGraph() *Graph {
+	return &Graph{index: make(map[string]int)}
+}
+
+func (g *Graph) node(name string) int {
+	if id, ok := g.index[name]; ok {
+		return id
+	}
+	id := len(g.names)
+	g.index[name] = id
+	g.names = append(g.names, name)
+	g.adj = append(g.adj, make(map[int]int))
+	return id
+}
+
+// AddEdge adds w to the weight between u and v. Self-loops are ignored.
+func (g *Graph) AddEdge(u, v string, w int) {
+	a, b := g.node(u), g.node(v)
+	if a == b {
+		return
+	}
+	g.adj[a][b] += w
+	g.adj[b][a] += w
+}
+
+func (g *Graph) Len() int { return len(g.names) }
+
+// Cut is a partition of the graph and the edges that cross it.
+type Cut struct {
+	Weight int
+	A, B   []string
+	Edges  [][2]string
+}
+
+// cutFromSide builds a Cut from a membership mask of one side.
+func (g *Graph) cutFromSide(inA []bool) Cut {
+	var cut Cut
+	for id, name := range g.names {
+		if inA[id] {
+			cut.A = append(cut.A, name)
+		} else {
+			cut.B = append(cut.B, name)
+		}
+		for nb, w := range g.adj[id] {
+			if inA[id] && !inA[nb] {
+				cut.Weight += w
+				e := [2]string{name, g.names[nb]}
+				if e[0] > e[1] {
+					e[0], e[1] = e[1], e[0]
+				}
+				cut.Edges = append(cut.Edges, e)
+			}
+		}
+	}
+	sort.Strings(cut.A)
+	sort.Strings(cut.B)
+	sort.Slice(cut.Edges, func(i, j int) bool {
+		if cut.Edges[i][0] != cut.Edges[j][0] {
+			return cut.Edges[i][0] < cut.Edges[j][0]
+		}
+		return cut.Edges[i][1] < cut.Edges[j][1]
+	})
+	return cut
+}
+
+type weighted struct{ node, key int }
+
+type maxHeap []weighted
+
+func (h maxHeap) Len() int           { return len(h) }
+func (h maxHeap) Less(i, j int) bool { return h[i].key > h[j].key }
+func (h maxHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(x any)        { *h = append(*h, x.(weighted)) }
+func (h *maxHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// StoerWagner computes the global minimum cut. Each maximum adjacency
+// phase runs on a heap over the contracted adjacency maps, so the whole
+// search is O(n·m·log n) rather than O(n³) on a dense matrix.
+func (g *Graph) StoerWagner() Cut {
+	n := g.Len()
+	if n < 2 {
+		return g.cutFromSide(make([]bool, n))
+	}
+
+	adj := make([]map[int]int, n)
+	members := make([][]int, n)
+	active := make([]int, n)
+	for i := range n {
+		adj[i] = make(map[int]int, len(g.adj[i]))
+		for nb, w := range g.adj[i] {
+			adj[i][nb] = w
+		}
+		members[i] = []int{i}
+		active[i] = i
+	}
+
+	best := math.MaxInt
+	var bestSide []int
+	key := make([]int, n)
+	added := make([]bool, n)
+
+	for len(active) > 1 {
+		for _, v := range active {
+			key[v], added[v] = 0, false
+		}
+		h := &maxHeap{{active[0], 0}}
+		order := make([]int, 0, len(active))
+
+		for h.Len() > 0 {
+			top := heap.Pop(h).(weighted)
+			v := top.node
+			if added[v] || top.key != key[v] {
+				continue
+			}
+			added[v] = true
+			order = append(order, v)
+			for nb, w := range adj[v] {
+				if !added[nb] {
+					key[nb] += w
+					heap.Push(h, weighted{nb, key[nb]})
+				}
+			}
+		}
+
+		if len(order) < len(active) {
+			// Disconnected: whatever the phase reached is a zero-weight side.
+			best, bestSide = 0, nil
+			for _, v := range order {
+				bestSide = append(bestSide, members[v]...)
+			}
+			break
+		}
+
+		s, t := order[len(order)-2], order[len(order)-1]
+		if key[t] < best {
+			best = key[t]
+			bestSide = append([]int(nil), members[t]...)
+		}
+
+		for nb, w := range adj[t] {
+			delete(adj[nb], t)
+			if nb != s {
+				adj[s][nb] += w
+				adj[nb][s] += w
+			}
+		}
+		adj[t] = nil
+		members[s] = append(members[s], members[t]...)
+		for i, v := range active {
+			if v == t {
+				active[i] = active[len(active)-1]
+				active = active[:len(active)-1]
+				break
+			}
+		}
+	}
+
+	inA := make([]bool, n)
+	for _, v := range bestSide {
+		inA[v] = true
+	}
+	return g.cutFromSide(inA)
+}
+
+// flowNetwork holds both directions of every undirected edge as a pair of
+// arcs, arc i and arc i^1, each able to carry the full edge weight.
+type flowNetwork struct {
+	head []int
+	next []int
+	to   []int
+	cap  []int
+	base []int
+}
+
+func (g *Graph) network() *flowNetwork {
+	f := &flowNetwork{head: make([]int, g.Len())}
+	for i := range f.head {
+		f.head[i] = -1
+	}
+	for u, nbs := range g.adj {
+		for v, w := range nbs {
+			if u < v {
+				f.arc(u, v, w)
+				f.arc(v, u, w)
+			}
+		}
+	}
+	f.base = append([]int(nil), f.cap...)
+	return f
+}
+
+func (f *flowNetwork) arc(u, v, w int) {
+	f.to = append(f.to, v)
+	f.cap = append(f.cap, w)
+	f.next = append(f.next, f.head[u])
+	f.head[u] = len(f.to) - 1
+}
+
+// maxFlow runs Edmonds–Karp from s to t, stopping early once the flow
+// reaches limit. It returns the flow and the residual-reachable side of s.
+func (f *flowNetwork) maxFlow(s, t, limit int) (int, []bool) {
+	copy(f.cap, f.base)
+	n := len(f.head)
+	via := make([]int, n)
+	flow := 0
+
+	for {
+		for i := range via {
+			via[i] = -2
+		}
+		via[s] = -1
+		queue := []int{s}
+		for len(queue) > 0 && via[t] == -2 {
+			u := queue[0]
+			queue = queue[1:]
+			for e := f.head[u]; e != -1; e = f.next[e] {
+				if v := f.to[e]; f.cap[e] > 0 && via[v] == -2 {
+					via[v] = e
+					queue = append(queue, v)
+				}
+			}
+		}
+
+		if via[t] == -2 || flow >= limit {
+			reach := make([]bool, n)
+			for i, e := range via {
+				reach[i] = e != -2
+			}
+			return flow, reach
+		}
+
+		push := math.MaxInt
+		for v := t; v != s; v = f.to[via[v]^1] {
+			push = min(push, f.cap[via[v]])
+		}
+		for v := t; v != s; v = f.to[via[v]^1] {
+			f.cap[via[v]] -= push
+			f.cap[via[v]^1] += push
+		}
+		flow += push
+	}
+}
+
+// MinCutBetween returns a minimum s–t cut via Edmonds–Karp.
+func (g *Graph) MinCutBetween(s, t string) (Cut, error) {
+	a, ok := g.index[s]
+	if !ok {
+		return Cut{}, fmt.Errorf("unknown node %q", s)
+	}
+	b, ok := g.index[t]
+	if !ok {
+		return Cut{}, fmt.Errorf("unknown node %q", t)
+	}
+	if a == b {
+		return Cut{}, fmt.Errorf("source and sink are both %q", s)
+	}
+	_, reach := g.network().maxFlow(a, b, math.MaxInt)
+	return g.cutFromSide(reach), nil
+}
+
+// MinCutFlow finds the global minimum cut as the smallest s–t cut over all
+// t for a fixed s. Each flow stops as soon as it matches the best cut so
+// far, so small cuts in large sparse graphs cost O(n·k·m) for cut size k.
+func (g *Graph) MinCutFlow() Cut {
+	n := g.Len()
+	if n < 2 {
+		return g.cutFromSide(make([]bool, n))
+	}
+
+	net := g.network()
+	best := math.MaxInt
+	var bestSide []bool
+	for t := 1; t < n && best > 0; t++ {
+		if flow, reach := net.maxFlow(0, t, best); flow < best {
+			best, bestSide = flow, reach
+		}
+	}
+	return g.cutFromSide(bestSide)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const example = `jqt: rhn xhk nvd
+rsh: frs pzl lsr
+xhk: hfx
+cmg: qnr nvd lhk bvb
+rhn: xhk bvb hfx
+bvb: xhk hfx
+pzl: lsr hfx nvd
+qnr: nvd
+ntq: jqt hfx bvb xhk
+nvd: lhk
+lsr: lhk
+rzs: qnr cmg lsr rsh
+frs: qnr lhk lsr`
+
+func exampleGraph() *Graph {
+	g := NewGraph()
+	for _, line := range strings.Split(example, "\n") {
+		u, rest, _ := strings.Cut(line, ":")
+		for _, v := range strings.Fields(rest) {
+			g.AddEdge(u, v, 1)
+		}
+	}
+	return g
+}
+
+func TestMinCutAlgorithmsAgree(t *testing.T) {
+	g := exampleGraph()
+	cuts := map[string]Cut{"flow": g.MinCutFlow(), "stoer-wagner": g.StoerWagner()}
+	want := [][2]string{{"bvb", "cmg"}, {"hfx", "pzl"}, {"jqt", "nvd"}}
+	for name, cut := range cuts {
+		if cut.Weight != 3 {
+			t.Errorf("%s: cut weight %d, want 3", name, cut.Weight)
+		}
+		if got := len(cut.A) * len(cut.B); got != 54 {
+			t.Errorf("%s: product %d, want 54", name, got)
+		}
+		if len(cut.Edges) != len(want) {
+			t.Errorf("%s: cut edges %v, want %v", name, cut.Edges, want)
+			continue
+		}
+		for i := range want {
+			if cut.Edges[i] != want[i] {
+				t.Errorf("%s: cut edges %v, want %v", name, cut.Edges, want)
+				break
+			}
+		}
+	}
+}
+
+func TestMinCutBetween(t *testing.T) {
+	g := exampleGraph()
+	cut, err := g.MinCutBetween("jqt", "cmg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cut.Weight != 3 || len(cut.A)*len(cut.B) != 54 {
+		t.Errorf("cut weight %d, sides %d and %d, want 3 and 54 as product", cut.Weight, len(cut.A), len(cut.B))
+	}
+
+	for _, pair := range [][2]string{{"jqt", "nope"}, {"nope", "jqt"}, {"jqt", "jqt"}} {
+		if _, err := g.MinCutBetween(pair[0], pair[1]); err == nil {
+			t.Errorf("MinCutBetween(%q, %q) succeeded, want an error", pair[0], pair[1])
+		}
+	}
+}
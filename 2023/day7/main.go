@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"os"
 	"slices"
@@ -9,170 +10,25 @@ import (
 	"strings"
 )
 
-type Hand struct {
-	cards string
-	bid   int
-	kind  int
-	ranks []int
-}
-
-const (
-	HighCard = iota + 1
-	OnePair
-	TwoPair
-	ThreeKind
-	FullHouse
-	FourKind
-	FiveKind
-)
-
-var cardRankPart1 = map[byte]int{
-	'2': 2, '3': 3, '4': 4, '5': 5, '6': 6, '7': 7, '8': 8, '9': 9,
-	'T': 10, 'J': 11, 'Q': 12, 'K': 13, 'A': 14,
-}
-
-var cardRankPart2 = map[byte]int{
-	'J': 1, '2': 2, '3': 3, '4': 4, '5': 5, '6': 6, '7': 7, '8': 8, '9': 9,
-	'T': 10, 'Q': 11, 'K': 12, 'A': 13,
-}
-
-func classifyHandPart1(cards string) int {
-	freq := make(map[byte]int)
-	for i := range len(cards) {
-		freq[cards[i]]++
-	}
-	maxFreq, pairs := 0, 0
-	for _, count := range freq {
-		if count > maxFreq {
-			maxFreq = count
-		}
-		if count == 2 {
-			pairs++
-		}
-	}
-	unique := len(freq)
-	switch {
-	case maxFreq == 5:
-		return FiveKind
-	case maxFreq == 4:
-		return FourKind
-	case maxFreq == 3 && unique == 2:
-		return FullHouse
-	case maxFreq == 3:
-		return ThreeKind
-	case pairs == 2:
-		return TwoPair
-	case maxFreq == 2:
-		return OnePair
-	default:
-		return HighCard
-	}
-}
-
-func classifyHandPart2(cards string) int {
-	freq := make(map[byte]int)
-	jokers := 0
-
-	for i := range len(cards) {
-		if cards[i] == 'J' {
-			jokers++
-		} else {
-			freq[cards[i]]++
-		}
-	}
-	if len(freq) == 0 {
-		return FiveKind
-	}
-	maxFreq, pairs := 0, 0
-	for _, count := range freq {
-		if count > maxFreq {
-			maxFreq = count
-		}
-		if count == 2 {
-			pairs++
-		}
-	}
-	maxFreq += jokers
-	if jokers > 0 {
-		pairs = 0
-		for _, count := range freq {
-			if count == 2 {
-				pairs++
-			}
-		}
-	}
-	unique := len(freq)
-	switch {
-	case maxFreq == 5:
-		return FiveKind
-	case maxFreq == 4:
-		return FourKind
-	case maxFreq == 3 && unique == 2:
-		return FullHouse
-	case maxFreq == 3:
-		return ThreeKind
-	case pairs == 2:
-		return TwoPair
-	case maxFreq == 2:
-		return OnePair
-	default:
-		return HighCard
-	}
-}
-
-func parseHand(line string, part2 bool) (Hand, error) {
-	parts := strings.Fields(line)
-	bid, _ := strconv.Atoi(parts[1])
-	cards := parts[0]
-	ranks := make([]int, 5)
-
-	var cardRank map[byte]int
-	var classifyFunc func(string) int
-	if part2 {
-		cardRank = cardRankPart2
-		classifyFunc = classifyHandPart2
-	} else {
-
-		cardRank = cardRankPart1
-		classifyFunc = classifyHandPart1
-	}
-	for i, card := range []byte(cards) {
-		rank := cardRank[card]
-		ranks[i] = rank
-	}
-	return Hand{
-		cards,
-		bid,
-		classifyFunc(cards),
-		ranks,
-	}, nil
-}
-
-func compareHands(a, b Hand) int {
-	if a.kind != b.kind {
-		return a.kind - b.kind
-	}
-	for i := range 5 {
-		if a.ranks[i] != b.ranks[i] {
-			return a.ranks[i] - b.ranks[i]
-		}
-	}
-	return 0
-}
-
-func solve(filePath string, part2 bool) int {
+func solve(filePath string, rules Rules) (int, error) {
 	var hands []Hand
 	file, err := os.Open(filePath)
 	if err != nil {
-		panic(err)
+		return 0, err
 	}
+	defer file.Close()
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" {
 			continue
 		}
-		hand, _ := parseHand(line, part2)
+		parts := strings.Fields(line)
+		bid, _ := strconv.Atoi(parts[1])
+		hand, err := rules.Evaluate(parts[0], bid)
+		if err != nil {
+			return 0, err
+		}
 		hands = append(hands, hand)
 	}
 	slices.SortFunc(hands, compareHands)
@@ -182,13 +38,47 @@ func solve(filePath string, part2 bool) int {
 		total += rank * hand.bid
 	}
 
-	return total
+	return total, nil
 }
 
 func main() {
-	resultPart1 := solve("input.txt", false)
-	fmt.Println(resultPart1)
+	explain := flag.String("explain", "", "two comma-separated hands to compare under both rule sets")
+	flag.Parse()
 
-	resultPart2 := solve("input.txt", true)
-	fmt.Println(resultPart2)
+	for _, rules := range []Rules{part1Rules, part2Rules} {
+		if err := rules.Validate(); err != nil {
+			fmt.Println("Invalid rules:", err)
+			return
+		}
+		if *explain != "" {
+			if err := explainPair(rules, *explain); err != nil {
+				fmt.Println(err)
+				return
+			}
+			continue
+		}
+		result, err := solve("input.txt", rules)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println(result)
+	}
+}
+
+func explainPair(rules Rules, pair string) error {
+	cards := strings.Split(pair, ",")
+	if len(cards) != 2 {
+		return fmt.Errorf("-explain wants two hands, got %q", pair)
+	}
+	a, err := rules.Evaluate(cards[0], 0)
+	if err != nil {
+		return err
+	}
+	b, err := rules.Evaluate(cards[1], 0)
+	if err != nil {
+		return err
+	}
+	fmt.Println(rules.Explain(a, b))
+	return nil
 }
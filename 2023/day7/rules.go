@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// HandType is a hand category defined by its group sizes, largest first:
+// a full house is {3, 2}.
+type HandType struct {
+	Name  string
+	Shape []int
+}
+
+var standardTypes = []HandType{
+	{"High card", []int{1, 1, 1, 1, 1}},
+	{"One pair", []int{2, 1, 1, 1}},
+	{"Two pair", []int{2, 2, 1}},
+	{"Three of a kind", []int{3, 1, 1}},
+	{"Full house", []int{3, 2}},
+	{"Four of a kind", []int{4, 1}},
+	{"Five of a kind", []int{5}},
+}
+
+// Rules configure the game. Order lists the cards weakest first, Wild the
+// cards that stand in for whatever makes the best hand type (they still
+// rank by Order when breaking ties), and Types the hand types weakest
+// first.
+type Rules struct {
+	Order    string
+	Wild     string
+	HandSize int
+	Types    []HandType
+}
+
+var (
+	part1Rules = Rules{Order: "23456789TJQKA", HandSize: 5, Types: standardTypes}
+	part2Rules = Rules{Order: "J23456789TQKA", Wild: "J", HandSize: 5, Types: standardTypes}
+)
+
+// Validate checks that every way of grouping HandSize cards has a type.
+func (r Rules) Validate() error {
+	for _, w := range []byte(r.Wild) {
+		if strings.IndexByte(r.Order, w) < 0 {
+			return fmt.Errorf("wild card %q is not in the card order", w)
+		}
+	}
+	var missing []string
+	var walk func(left, most int, shape []int)
+	walk = func(left, most int, shape []int) {
+		if left == 0 {
+			if r.typeOf(shape) < 0 {
+				missing = append(missing, fmt.Sprint(shape))
+			}
+			return
+		}
+		for n := min(left, most); n >= 1; n-- {
+			walk(left-n, n, append(shape, n))
+		}
+	}
+	walk(r.HandSize, r.HandSize, nil)
+	if len(missing) > 0 {
+		return fmt.Errorf("no hand type for groupings %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (r Rules) typeOf(shape []int) int {
+	for i, t := range r.Types {
+		if slices.Equal(t.Shape, shape) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Hand is an evaluated hand: its type as an index into Rules.Types and
+// each card's position in Rules.Order.
+type Hand struct {
+	cards string
+	bid   int
+	kind  int
+	ranks []int
+	wilds int
+}
+
+// Evaluate classifies cards. Wild cards are tried in every group, and as
+// new groups of their own, keeping whichever gives the strongest type.
+func (r Rules) Evaluate(cards string, bid int) (Hand, error) {
+	if len(cards) != r.HandSize {
+		return Hand{}, fmt.Errorf("hand %q has %d cards, want %d", cards, len(cards), r.HandSize)
+	}
+	h := Hand{cards: cards, bid: bid, ranks: make([]int, len(cards))}
+	freq := make(map[byte]int)
+	for i := range len(cards) {
+		rank := strings.IndexByte(r.Order, cards[i])
+		if rank < 0 {
+			return Hand{}, fmt.Errorf("unknown card %q in %q", cards[i], cards)
+		}
+		h.ranks[i] = rank
+		if strings.IndexByte(r.Wild, cards[i]) >= 0 {
+			h.wilds++
+		} else {
+			freq[cards[i]]++
+		}
+	}
+	var groups []int
+	for _, count := range freq {
+		groups = append(groups, count)
+	}
+
+	h.kind = -1
+	var place func(groups []int, wilds int)
+	place = func(groups []int, wilds int) {
+		if wilds == 0 {
+			shape := slices.Clone(groups)
+			slices.SortFunc(shape, func(a, b int) int { return b - a })
+			h.kind = max(h.kind, r.typeOf(shape))
+			return
+		}
+		for i := range groups {
+			groups[i]++
+			place(groups, wilds-1)
+			groups[i]--
+		}
+		place(append(groups, 1), wilds-1)
+	}
+	place(groups, h.wilds)
+	if h.kind < 0 {
+		return Hand{}, fmt.Errorf("no hand type matches %q", cards)
+	}
+	return h, nil
+}
+
+func compareHands(a, b Hand) int {
+	if a.kind != b.kind {
+		return a.kind - b.kind
+	}
+	for i := range a.ranks {
+		if a.ranks[i] != b.ranks[i] {
+			return a.ranks[i] - b.ranks[i]
+		}
+	}
+	return 0
+}
+
+// Explain says which of two hands ranks higher and what decided it.
+func (r Rules) Explain(a, b Hand) string {
+	describe := func(h Hand) string {
+		s := fmt.Sprintf("%s (%s", h.cards, r.Types[h.kind].Name)
+		if h.wilds > 0 {
+			s += fmt.Sprintf(", %d wild", h.wilds)
+		}
+		return s + ")"
+	}
+	c := compareHands(a, b)
+	if c < 0 {
+		a, b = b, a
+	}
+	switch {
+	case c == 0:
+		return fmt.Sprintf("%s ties with %s", describe(a), describe(b))
+	case a.kind != b.kind:
+		return fmt.Sprintf("%s beats %s: %s outranks %s", describe(a), describe(b), r.Types[a.kind].Name, r.Types[b.kind].Name)
+	}
+	for i := range a.ranks {
+		if a.ranks[i] != b.ranks[i] {
+			return fmt.Sprintf("%s beats %s: same type, decided by card %d, %c over %c",
+				describe(a), describe(b), i+1, a.cards[i], b.cards[i])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"math/big"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"aoc/memo"
+)
+
+// Value is a stone's number. It lives in n while it fits in a uint64 and
+// only moves to its decimal string in large when it does not, which keeps
+// Value comparable so it can key a map.
+type Value struct {
+	n     uint64
+	large string
+}
+
+func Small(n uint64) Value { return Value{n: n} }
+
+// ParseValue reads a decimal number, dropping leading zeros.
+func ParseValue(s string) (Value, bool) {
+	if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return Value{n: n}, true
+	}
+	b, ok := new(big.Int).SetString(s, 10)
+	if !ok || b.Sign() < 0 {
+		return Value{}, false
+	}
+	return Value{large: b.String()}, true
+}
+
+func (v Value) IsZero() bool { return v.large == "" && v.n == 0 }
+
+func (v Value) String() string {
+	if v.large != "" {
+		return v.large
+	}
+	return strconv.FormatUint(v.n, 10)
+}
+
+// Mul multiplies by k, moving to big.Int only if the product overflows.
+func (v Value) Mul(k uint64) Value {
+	if v.large == "" {
+		if hi, lo := bits.Mul64(v.n, k); hi == 0 {
+			return Value{n: lo}
+		}
+	}
+	b, _ := new(big.Int).SetString(v.String(), 10)
+	return Value{large: b.Mul(b, new(big.Int).SetUint64(k)).String()}
+}
+
+// Count is a number of stones: a uint64 until an addition overflows, then
+// a big.Int.
+type Count struct {
+	n     uint64
+	large *big.Int
+}
+
+func (c Count) Add(d Count) Count {
+	if c.large == nil && d.large == nil {
+		if sum, carry := bits.Add64(c.n, d.n, 0); carry == 0 {
+			return Count{n: sum}
+		}
+	}
+	return Count{large: new(big.Int).Add(c.Big(), d.Big())}
+}
+
+func (c Count) Big() *big.Int {
+	if c.large != nil {
+		return c.large
+	}
+	return new(big.Int).SetUint64(c.n)
+}
+
+func (c Count) String() string { return c.Big().String() }
+
+// Rule rewrites a stone when Match holds. Rules are tried in order and the
+// first match wins.
+type Rule struct {
+	Name  string
+	Match func(Value) bool
+	Apply func(Value) []Value
+}
+
+var blinkRules = []Rule{
+	{
+		Name:  "0 becomes 1",
+		Match: Value.IsZero,
+		Apply: func(Value) []Value { return []Value{Small(1)} },
+	},
+	{
+		Name:  "even number of digits splits in two",
+		Match: func(v Value) bool { return len(v.String())%2 == 0 },
+		Apply: func(v Value) []Value {
+			s := v.String()
+			left, _ := ParseValue(s[:len(s)/2])
+			right, _ := ParseValue(s[len(s)/2:])
+			return []Value{left, right}
+		},
+	},
+	{
+		Name:  "otherwise multiply by 2024",
+		Match: func(Value) bool { return true },
+		Apply: func(v Value) []Value { return []Value{v.Mul(2024)} },
+	},
+}
+
+// Stones tracks how many stones carry each value; stones with equal
+// values always evolve alike, so order does not matter.
+type Stones struct {
+	counts map[Value]Count
+	rules  []Rule
+	cache  *memo.Cache[Value, []Value]
+}
+
+func NewStones(values []Value, rules []Rule) *Stones {
+	s := &Stones{counts: make(map[Value]Count), rules: rules, cache: memo.NewCache[Value, []Value]()}
+	for _, v := range values {
+		s.counts[v] = s.counts[v].Add(Count{n: 1})
+	}
+	return s
+}
+
+func (s *Stones) apply(v Value) []Value {
+	if out, ok := s.cache.Get(v); ok {
+		return out
+	}
+	var out []Value
+	for _, r := range s.rules {
+		if r.Match(v) {
+			out = r.Apply(v)
+			break
+		}
+	}
+	s.cache.Put(v, out)
+	return out
+}
+
+// Blink applies the rules to every stone once. A stone no rule matches
+// disappears.
+func (s *Stones) Blink() {
+	next := make(map[Value]Count, len(s.counts))
+	for v, c := range s.counts {
+		for _, child := range s.apply(v) {
+			next[child] = next[child].Add(c)
+		}
+	}
+	s.counts = next
+}
+
+func (s *Stones) Total() Count {
+	var total Count
+	for _, c := range s.counts {
+		total = total.Add(c)
+	}
+	return total
+}
+
+// Distinct is how many different values are on the stones.
+func (s *Stones) Distinct() int { return len(s.counts) }
+
+// Largest is the biggest value on any stone, as a string when it no longer
+// fits in a uint64.
+func (s *Stones) Largest() string {
+	var largest uint64
+	var huge *big.Int
+	for v := range s.counts {
+		if v.large == "" {
+			largest = max(largest, v.n)
+			continue
+		}
+		b, _ := new(big.Int).SetString(v.large, 10)
+		if huge == nil || b.Cmp(huge) > 0 {
+			huge = b
+		}
+	}
+	if huge != nil {
+		return huge.String()
+	}
+	return strconv.FormatUint(largest, 10)
+}
+
+func parseStones(line string) ([]Value, bool) {
+	var values []Value
+	for _, part := range strings.Fields(line) {
+		v, ok := ParseValue(part)
+		if !ok {
+			return nil, false
+		}
+		values = append(values, v)
+	}
+	return values, true
+}
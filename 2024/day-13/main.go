@@ -8,6 +8,8 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	"aoc/linalg"
 )
 
 type Point struct {
@@ -21,43 +23,28 @@ type Machine struct {
 }
 
 func solveLinearSystem(buttonA, buttonB, Prize Point) (int, error) {
-	// INFO: Solve using elimination method
-
-	a1, b1, c1 := buttonA.X, buttonB.X, Prize.X
-	a2, b2, c2 := buttonA.Y, buttonB.Y, Prize.Y
-
-	m1, m2 := b2, b1
-
-	e1a, e1c := a1*m1, c1*m1
-	e2a, e2c := a2*m2, c2*m2
-
-	finalA, finalC := e1a-e2a, e1c-e2c
-
-	if finalA == 0 {
+	// INFO: a·buttonA + b·buttonB = Prize, solved over the integers
+	A := linalg.IntMatrix{
+		{int64(buttonA.X), int64(buttonB.X)},
+		{int64(buttonA.Y), int64(buttonB.Y)},
+	}
+	det, err := A.Det()
+	if err != nil {
+		return 0, err
+	}
+	if det == 0 {
 		return 0, errors.New("division by zero: no unique solution")
 	}
 
-	if finalC%finalA != 0 {
-		return 0, fmt.Errorf("a is not an integer: %d / %d", finalC, finalA)
+	presses, err := linalg.SolveInt(A, []int64{int64(Prize.X), int64(Prize.Y)})
+	if err != nil {
+		return 0, err
 	}
-
-	a := finalC / finalA
+	a, b := int(presses[0]), int(presses[1])
 
 	// INFO: PART 1: constraint
-	// if a < 0 || a > 100 {
-	// 	return 0, fmt.Errorf("a is out of bound: %d", a)
-	// }
-
-	numerator := c1 - a1*a
-	if numerator%b1 != 0 {
-		return 0, fmt.Errorf("b is not an integer: %d / %d", numerator, b1)
-	}
-
-	b := numerator / b1
-
-	// INFO: PART1: constraint
-	// if b < 0 || b > 100 {
-	// 	return 0, fmt.Errorf("b is out of bound %d", b)
+	// if a > 100 || b > 100 {
+	// 	return 0, fmt.Errorf("presses out of bound: %d, %d", a, b)
 	// }
 
 	if a < 0 || b < 0 {
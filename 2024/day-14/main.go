@@ -2,10 +2,14 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"flag"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
+
+	"aoc/budget"
 )
 
 type Robot struct {
@@ -76,86 +80,34 @@ func calculateSafetyFactor(robots []Robot, seconds, width, height int) int {
 	return safetyFactor
 }
 
-func calculateClustering(robots []Robot, seconds, width, height int) int {
-	positions := make(map[[2]int]bool)
-	for _, robot := range robots {
-		x, y := simulatePosition(robot, seconds, width, height)
-		positions[[2]int{x, y}] = true
-	}
-
-	clustered := 0
-	for pos := range positions {
-		x, y := pos[0], pos[1]
-
-		neighbors := 0
-		for dx := -1; dx <= 1; dx++ {
-			for dy := -1; dy <= 1; dy++ {
-				if dx == 0 && dy == 0 {
-					continue
-				}
-				nx, ny := x+dx, y+dy
-				if positions[[2]int{nx, ny}] {
-					neighbors++
-				}
-			}
-		}
-		if neighbors > 0 {
-			clustered++
-		}
+// findChristmasTree ranks every second of the swarm's period with d and
+// returns the best k candidates.
+func findChristmasTree(ctx context.Context, swarm *Swarm, d Detector, k int) ([]Candidate, error) {
+	candidates, err := swarm.Rank(ctx, d, k)
+	for i, c := range candidates {
+		fmt.Printf("%d. second %d (%s score %.3f)\n", i+1, c.Second, d.Name(), c.Score)
 	}
-	return clustered
+	return candidates, err
 }
 
-func visualizeRobots(robots []Robot, seconds, width, height int) {
-	grid := make([][]int, height)
-	for i := range grid {
-		grid[i] = make([]int, width)
-	}
-
-	for _, robot := range robots {
-		x, y := simulatePosition(robot, seconds, width, height)
-		grid[y][x]++
-	}
+// ranking prints as the second of its best candidate, or as nothing when
+// it is empty.
+type ranking []Candidate
 
-	fmt.Printf("\n Second %d:\n", seconds)
-	for _, row := range grid {
-		for _, count := range row {
-			if count == 0 {
-				fmt.Print(".")
-			} else {
-				fmt.Print("#")
-			}
-		}
-		fmt.Println()
+func (r ranking) String() string {
+	if len(r) == 0 {
+		return ""
 	}
-	fmt.Println()
-}
-
-func findChristmasTree(robots []Robot, width, height int) int {
-	maxClustering := 0
-	bestSecond := 0
-
-	for seconds := 1; seconds <= 10000; seconds++ {
-		clustering := calculateClustering(robots, seconds, width, height)
-
-		if clustering > maxClustering {
-			maxClustering = clustering
-			bestSecond = seconds
-
-			if clustering > len(robots)/2 {
-				fmt.Printf("High clustering at second %d: %d robots clustered\n", seconds, clustering)
-				// visualizeRobots(robots, seconds, width, height)
-			}
-		}
-
-		if seconds%1000 == 0 {
-			fmt.Printf("Checked %d seconds, max clustering: %d at second %d\n", seconds, maxClustering, bestSecond)
-		}
-	}
-	return bestSecond
+	return strconv.Itoa(r[0].Second)
 }
 
 func main() {
+	timeout := flag.Duration("timeout", 0, "time budget per part, e.g. 2s (0 = unlimited)")
+	detectorName := flag.String("detector", "cluster", "picture detector: "+strings.Join(detectorNames(), ", "))
+	top := flag.Int("top", 5, "number of candidate seconds to rank")
+	export := flag.String("export", "", "directory to write the top candidates' frames to")
+	flag.Parse()
+
 	file, err := os.Open("input.txt")
 	if err != nil {
 		fmt.Println("Error opening file:", err)
@@ -178,24 +130,37 @@ func main() {
 	seconds := 100
 
 	// INFO: PART 1
-	safetyFactor := calculateSafetyFactor(robots, seconds, width, height)
-	fmt.Println(safetyFactor)
+	budget.Run("Part 1", *timeout, func(ctx context.Context) (any, error) {
+		return calculateSafetyFactor(robots, seconds, width, height), nil
+	})
 
 	// INFO: PART 2
-	// Part 2
-	fmt.Println("\nSearching for Christmas tree pattern...")
-	treeSecond := findChristmasTree(robots, width, height)
-	fmt.Printf("Part 2 - Christmas tree appears at second: %d\n", treeSecond)
-
-	fmt.Printf("\nChecking second 6752 specifically:")
-	clustering6752 := calculateClustering(robots, 6752, width, height)
-	fmt.Printf("Second 6752 clustering: %d\n", clustering6752)
+	detector, ok := detectors[*detectorName]
+	if !ok {
+		fmt.Printf("unknown detector %q (have %s)\n", *detectorName, strings.Join(detectorNames(), ", "))
+		return
+	}
+	swarm := NewSwarm(robots, width, height)
+	fmt.Printf("Swarm repeats every %d seconds\n", swarm.Period())
 
-	fmt.Println("\nVisualization at detected best second:")
-	visualizeRobots(robots, treeSecond, width, height)
+	if crt, err := swarm.VarianceCRT(); err == nil {
+		fmt.Println("Variance minimum via CRT at second", crt)
+	}
 
-	if treeSecond != 6752 {
-		fmt.Println("\nVisualization at second 6752:")
-		visualizeRobots(robots, 6752, width, height)
+	fmt.Println("\nSearching for Christmas tree pattern...")
+	v, _ := budget.Run("Part 2 - Christmas tree appears at second", *timeout, func(ctx context.Context) (any, error) {
+		candidates, err := findChristmasTree(ctx, swarm, detector, *top)
+		return ranking(candidates), err
+	})
+	candidates, _ := v.(ranking)
+
+	if *export != "" {
+		if err := swarm.ExportFrames(*export, candidates); err != nil {
+			fmt.Println("Error exporting frames:", err)
+		}
+	}
+	if len(candidates) > 0 {
+		fmt.Println("\nVisualization at detected best second:")
+		fmt.Print(swarm.Frame(candidates[0].Second))
 	}
 }
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"aoc/cycle"
+)
+
+// Swarm is a set of robots on a wrapping grid. Every robot moves in a
+// straight line, so its position at any second is a single modular step
+// from its start and frames can be computed in any order.
+type Swarm struct {
+	robots        []Robot
+	width, height int
+	period        int
+}
+
+func NewSwarm(robots []Robot, width, height int) *Swarm {
+	s := &Swarm{robots: robots, width: width, height: height}
+	s.period = s.findPeriod()
+	return s
+}
+
+// Period is the number of seconds after which every robot is back at its
+// start.
+func (s *Swarm) Period() int { return s.period }
+
+// findPeriod runs Brent's cycle search over the whole swarm. Each axis
+// repeats on its own grid size, so the period divides lcm(w, h), but it is
+// shorter when every velocity shares a factor with the grid.
+func (s *Swarm) findPeriod() int {
+	step := func(rs []Robot) []Robot {
+		next := make([]Robot, len(rs))
+		for i, r := range rs {
+			r.x, r.y = simulatePosition(r, 1, s.width, s.height)
+			next[i] = r
+		}
+		return next
+	}
+	key := func(rs []Robot) string {
+		buf := make([]byte, 0, len(rs)*8)
+		for _, r := range rs {
+			buf = strconv.AppendInt(buf, int64(r.x), 10)
+			buf = append(buf, ',')
+			buf = strconv.AppendInt(buf, int64(r.y), 10)
+			buf = append(buf, ';')
+		}
+		return string(buf)
+	}
+	return cycle.Brent(s.robots, step, key).Length
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// Frame is the occupancy of the grid at one second.
+type Frame struct {
+	Second        int
+	Width, Height int
+	Count         []int
+	xs, ys        []int
+}
+
+func (s *Swarm) Frame(second int) Frame {
+	f := Frame{
+		Second: second,
+		Width:  s.width,
+		Height: s.height,
+		Count:  make([]int, s.width*s.height),
+		xs:     make([]int, len(s.robots)),
+		ys:     make([]int, len(s.robots)),
+	}
+	for i, r := range s.robots {
+		x, y := simulatePosition(r, second, s.width, s.height)
+		f.Count[y*s.width+x]++
+		f.xs[i], f.ys[i] = x, y
+	}
+	return f
+}
+
+func (f Frame) At(x, y int) int {
+	if x < 0 || x >= f.Width || y < 0 || y >= f.Height {
+		return 0
+	}
+	return f.Count[y*f.Width+x]
+}
+
+func (f Frame) String() string {
+	var sb strings.Builder
+	for y := range f.Height {
+		for x := range f.Width {
+			if f.At(x, y) == 0 {
+				sb.WriteByte('.')
+			} else {
+				sb.WriteByte('#')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// Detector scores how much a frame looks like a picture; higher is more
+// likely.
+type Detector interface {
+	Name() string
+	Score(f Frame) float64
+}
+
+var detectors = map[string]Detector{}
+
+func RegisterDetector(d Detector) {
+	detectors[d.Name()] = d
+}
+
+func init() {
+	RegisterDetector(clusterDetector{})
+	RegisterDetector(compressionDetector{})
+	RegisterDetector(runDetector{})
+	RegisterDetector(varianceDetector{})
+}
+
+func detectorNames() []string {
+	names := make([]string, 0, len(detectors))
+	for name := range detectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// clusterDetector is the fraction of occupied cells with an occupied
+// neighbour.
+type clusterDetector struct{}
+
+func (clusterDetector) Name() string { return "cluster" }
+
+func (clusterDetector) Score(f Frame) float64 {
+	occupied, clustered := 0, 0
+	for y := range f.Height {
+		for x := range f.Width {
+			if f.At(x, y) == 0 {
+				continue
+			}
+			occupied++
+		neighbours:
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					if (dx != 0 || dy != 0) && f.At(x+dx, y+dy) > 0 {
+						clustered++
+						break neighbours
+					}
+				}
+			}
+		}
+	}
+	if occupied == 0 {
+		return 0
+	}
+	return float64(clustered) / float64(occupied)
+}
+
+// compressionDetector treats a picture as a low-entropy frame: the better
+// the rendered grid deflates, the more structure it has.
+type compressionDetector struct{}
+
+func (compressionDetector) Name() string { return "compression" }
+
+func (compressionDetector) Score(f Frame) float64 {
+	raw := []byte(f.String())
+	var buf bytes.Buffer
+	w, _ := flate.NewWriter(&buf, flate.BestCompression)
+	w.Write(raw)
+	w.Close()
+	return float64(len(raw)) / float64(buf.Len())
+}
+
+// runDetector is the longest horizontal run of occupied cells; random
+// noise rarely lines up more than a few robots in a row.
+type runDetector struct{}
+
+func (runDetector) Name() string { return "run" }
+
+func (runDetector) Score(f Frame) float64 {
+	longest := 0
+	for y := range f.Height {
+		run := 0
+		for x := range f.Width {
+			if f.At(x, y) > 0 {
+				run++
+				longest = max(longest, run)
+			} else {
+				run = 0
+			}
+		}
+	}
+	return float64(longest)
+}
+
+// varianceDetector scores a frame by how tightly the robots gather on both
+// axes at once.
+type varianceDetector struct{}
+
+func (varianceDetector) Name() string { return "variance" }
+
+func (varianceDetector) Score(f Frame) float64 {
+	return -(variance(f.xs) + variance(f.ys))
+}
+
+func variance(vs []int) float64 {
+	if len(vs) == 0 {
+		return 0
+	}
+	sum, sq := 0.0, 0.0
+	for _, v := range vs {
+		sum += float64(v)
+		sq += float64(v) * float64(v)
+	}
+	mean := sum / float64(len(vs))
+	return sq/float64(len(vs)) - mean*mean
+}
+
+// VarianceCRT finds the picture without scoring whole frames. The x
+// coordinates repeat every width seconds and the y coordinates every height
+// seconds, so the tightest x spread is found within the first width seconds
+// and the tightest y spread within the first height seconds; the Chinese
+// remainder theorem then gives the one second where both happen together.
+func (s *Swarm) VarianceCRT() (int, error) {
+	tightest := func(n int, coord func(r Robot, t int) int) int {
+		best, bestVar := 0, 0.0
+		vs := make([]int, len(s.robots))
+		for t := range n {
+			for i, r := range s.robots {
+				vs[i] = coord(r, t)
+			}
+			if v := variance(vs); t == 0 || v < bestVar {
+				best, bestVar = t, v
+			}
+		}
+		return best
+	}
+	tx := tightest(s.width, func(r Robot, t int) int {
+		x, _ := simulatePosition(r, t, s.width, s.height)
+		return x
+	})
+	ty := tightest(s.height, func(r Robot, t int) int {
+		_, y := simulatePosition(r, t, s.width, s.height)
+		return y
+	})
+
+	lcm := s.width / gcd(s.width, s.height) * s.height
+	for t := tx; t < lcm; t += s.width {
+		if t%s.height == ty {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("no second ≡ %d (mod %d) and ≡ %d (mod %d)", tx, s.width, ty, s.height)
+}
+
+// Candidate is a second and the score a detector gave its frame.
+type Candidate struct {
+	Second int
+	Score  float64
+}
+
+// Rank scores every second of one period with d and returns the top k,
+// best first. If ctx ends first it ranks the seconds scored so far.
+func (s *Swarm) Rank(ctx context.Context, d Detector, k int) ([]Candidate, error) {
+	var all []Candidate
+	var err error
+	for t := range s.Period() {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		all = append(all, Candidate{t, d.Score(s.Frame(t))})
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Score > all[j].Score })
+	if len(all) > k {
+		all = all[:k]
+	}
+	return all, err
+}
+
+// ExportFrames writes each candidate's frame to dir as second-NNNNN.txt.
+func (s *Swarm) ExportFrames(dir string, candidates []Candidate) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, c := range candidates {
+		name := filepath.Join(dir, fmt.Sprintf("second-%05d.txt", c.Second))
+		if err := os.WriteFile(name, []byte(s.Frame(c.Second).String()), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Box is a rigid polyomino that moves as one piece. Glyphs holds the
+// character drawn at each of its cells.
+type Box struct {
+	Cells  []Point
+	Glyphs []byte
+}
+
+// GPS is 100 times the distance from the top edge plus the distance from
+// the left edge, measured to the box's closest edges.
+func (b Box) GPS() int {
+	top, left := b.Cells[0].Y, b.Cells[0].X
+	for _, c := range b.Cells[1:] {
+		top, left = min(top, c.Y), min(left, c.X)
+	}
+	return 100*top + left
+}
+
+const (
+	emptyCell = 0
+	wallCell  = -1
+)
+
+// Warehouse tracks which piece occupies every cell. A cell holds emptyCell,
+// wallCell, box i as i+1, or robot j as -(j+2).
+type Warehouse struct {
+	width, height int
+	cells         []int
+	boxes         []Box
+	robots        []Point
+	history       []step
+	undone        []step
+	transcript    io.Writer
+}
+
+// step records one instruction so it can be undone: the robot that moved
+// and every box it pushed.
+type step struct {
+	robot int
+	dir   Point
+	moved []int
+}
+
+// Config describes how a map is read before simulating. Expand replaces
+// each map character with a string, which is how the wide warehouse is
+// derived from the normal one.
+type Config struct {
+	Name   string
+	Expand map[byte]string
+}
+
+var (
+	normalConfig = Config{Name: "normal"}
+	wideConfig   = Config{
+		Name:   "wide",
+		Expand: map[byte]string{'#': "##", 'O': "[]", '.': "..", '@': "@."},
+	}
+)
+
+func (cfg Config) expand(lines []string) []string {
+	if cfg.Expand == nil {
+		return lines
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		var sb strings.Builder
+		for j := range len(line) {
+			if rep, ok := cfg.Expand[line[j]]; ok {
+				sb.WriteString(rep)
+			} else {
+				sb.WriteByte(line[j])
+			}
+		}
+		out[i] = sb.String()
+	}
+	return out
+}
+
+// NewWarehouse reads a map after applying cfg. '#' is a wall and '@' a
+// robot, numbered in reading order. 'O' is a single-cell box and "[]" a
+// two-cell one; any other letter marks a polyomino box made of the
+// 4-connected cells sharing that letter.
+func NewWarehouse(lines []string, cfg Config) (*Warehouse, error) {
+	lines = cfg.expand(lines)
+	w := &Warehouse{height: len(lines)}
+	for _, line := range lines {
+		w.width = max(w.width, len(line))
+	}
+	w.cells = make([]int, w.width*w.height)
+	glyph := func(p Point) byte {
+		if p.Y < 0 || p.Y >= len(lines) || p.X < 0 || p.X >= len(lines[p.Y]) {
+			return '#'
+		}
+		return lines[p.Y][p.X]
+	}
+
+	for y, line := range lines {
+		for x := range len(line) {
+			p := Point{x, y}
+			if w.at(p) != emptyCell {
+				continue
+			}
+			switch c := line[x]; {
+			case c == '.':
+			case c == '#':
+				w.set(p, wallCell)
+			case c == '@':
+				w.robots = append(w.robots, p)
+				w.set(p, -(len(w.robots) + 1))
+			case c == 'O':
+				w.addBox([]Point{p}, glyph)
+			case c == '[':
+				if glyph(Point{x + 1, y}) != ']' {
+					return nil, fmt.Errorf("unmatched '[' at %d,%d", x, y)
+				}
+				w.addBox([]Point{p, {x + 1, y}}, glyph)
+			case c == ']':
+				return nil, fmt.Errorf("unmatched ']' at %d,%d", x, y)
+			case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+				cells := []Point{p}
+				seen := map[Point]bool{p: true}
+				for i := 0; i < len(cells); i++ {
+					for _, d := range []Point{{0, 1}, {0, -1}, {1, 0}, {-1, 0}} {
+						n := Point{cells[i].X + d.X, cells[i].Y + d.Y}
+						if !seen[n] && glyph(n) == c {
+							seen[n] = true
+							cells = append(cells, n)
+						}
+					}
+				}
+				w.addBox(cells, glyph)
+			default:
+				return nil, fmt.Errorf("unknown map character %q at %d,%d", c, x, y)
+			}
+		}
+	}
+	if len(w.robots) == 0 {
+		return nil, fmt.Errorf("no robot in map")
+	}
+	return w, nil
+}
+
+func (w *Warehouse) addBox(cells []Point, glyph func(Point) byte) {
+	b := Box{Cells: cells, Glyphs: make([]byte, len(cells))}
+	for i, c := range cells {
+		b.Glyphs[i] = glyph(c)
+	}
+	w.boxes = append(w.boxes, b)
+	w.place(len(w.boxes) - 1)
+}
+
+func (w *Warehouse) at(p Point) int {
+	if p.X < 0 || p.X >= w.width || p.Y < 0 || p.Y >= w.height {
+		return wallCell
+	}
+	return w.cells[p.Y*w.width+p.X]
+}
+
+func (w *Warehouse) set(p Point, v int) { w.cells[p.Y*w.width+p.X] = v }
+
+func (w *Warehouse) place(box int) {
+	for _, c := range w.boxes[box].Cells {
+		w.set(c, box+1)
+	}
+}
+
+func (w *Warehouse) lift(box int) {
+	for _, c := range w.boxes[box].Cells {
+		w.set(c, emptyCell)
+	}
+}
+
+// shift moves every listed box and the robot by d.
+func (w *Warehouse) shift(robot int, boxes []int, d Point) {
+	for _, b := range boxes {
+		w.lift(b)
+	}
+	r := w.robots[robot]
+	w.set(r, emptyCell)
+	for _, b := range boxes {
+		for i, c := range w.boxes[b].Cells {
+			w.boxes[b].Cells[i] = Point{c.X + d.X, c.Y + d.Y}
+		}
+		w.place(b)
+	}
+	w.robots[robot] = Point{r.X + d.X, r.Y + d.Y}
+	w.set(w.robots[robot], -(robot + 2))
+}
+
+// pushed returns the boxes that must move for robot to step in direction
+// d, or false if a wall or another robot is in the way. Robots are never
+// pushed.
+func (w *Warehouse) pushed(robot int, d Point) ([]int, bool) {
+	var boxes []int
+	queued := make(map[int]bool)
+	frontier := []Point{w.robots[robot]}
+	for len(frontier) > 0 {
+		p := frontier[len(frontier)-1]
+		frontier = frontier[:len(frontier)-1]
+		switch v := w.at(Point{p.X + d.X, p.Y + d.Y}); {
+		case v == emptyCell:
+		case v > 0:
+			if !queued[v-1] {
+				queued[v-1] = true
+				boxes = append(boxes, v-1)
+				frontier = append(frontier, w.boxes[v-1].Cells...)
+			}
+		default:
+			if v != -(robot + 2) {
+				return nil, false
+			}
+		}
+	}
+	sort.Ints(boxes)
+	return boxes, true
+}
+
+// Move tries to step robot one cell in direction d, pushing whatever
+// boxes are in the way. It reports whether the robot moved. Any move,
+// blocked or not, discards the moves left to Replay.
+func (w *Warehouse) Move(robot int, d Point) bool {
+	w.undone = w.undone[:0]
+	boxes, ok := w.pushed(robot, d)
+	if ok {
+		w.shift(robot, boxes, d)
+		w.history = append(w.history, step{robot, d, boxes})
+	}
+	if w.transcript != nil {
+		fmt.Fprintf(w.transcript, "Move %s by robot %d: ", dirName(d), robot)
+		switch {
+		case !ok:
+			fmt.Fprintln(w.transcript, "blocked")
+		case len(boxes) == 0:
+			fmt.Fprintln(w.transcript, "moved")
+		default:
+			fmt.Fprintf(w.transcript, "pushed %d box(es)\n", len(boxes))
+		}
+		fmt.Fprintln(w.transcript, w)
+	}
+	return ok
+}
+
+// Run applies each instruction to every robot in turn and returns how many
+// individual moves succeeded.
+func (w *Warehouse) Run(moves string) int {
+	succeeded := 0
+	for i := range len(moves) {
+		dx, dy := getDirection(moves[i])
+		if dx == 0 && dy == 0 {
+			continue
+		}
+		for r := range w.robots {
+			if w.Move(r, Point{dx, dy}) {
+				succeeded++
+			}
+		}
+	}
+	return succeeded
+}
+
+// Undo reverts the last n successful moves and returns how many were
+// actually undone. Blocked moves changed nothing and are not recorded.
+func (w *Warehouse) Undo(n int) int {
+	undone := 0
+	for ; undone < n && len(w.history) > 0; undone++ {
+		s := w.history[len(w.history)-1]
+		w.history = w.history[:len(w.history)-1]
+		w.shift(s.robot, s.moved, Point{-s.dir.X, -s.dir.Y})
+		w.undone = append(w.undone, s)
+	}
+	return undone
+}
+
+// Replay reapplies up to n undone moves, most recently undone first, and
+// returns how many it replayed. Any new move discards what is left.
+func (w *Warehouse) Replay(n int) int {
+	replayed := 0
+	for ; replayed < n && len(w.undone) > 0; replayed++ {
+		s := w.undone[len(w.undone)-1]
+		w.undone = w.undone[:len(w.undone)-1]
+		w.shift(s.robot, s.moved, s.dir)
+		w.history = append(w.history, s)
+	}
+	return replayed
+}
+
+// SetTranscript makes every move print its outcome and the resulting map.
+func (w *Warehouse) SetTranscript(out io.Writer) { w.transcript = out }
+
+func (w *Warehouse) GPS() int {
+	sum := 0
+	for _, b := range w.boxes {
+		sum += b.GPS()
+	}
+	return sum
+}
+
+func (w *Warehouse) String() string {
+	grid := make([][]byte, w.height)
+	for y := range grid {
+		grid[y] = []byte(strings.Repeat(".", w.width))
+		for x := range w.width {
+			if w.at(Point{x, y}) == wallCell {
+				grid[y][x] = '#'
+			}
+		}
+	}
+	for _, b := range w.boxes {
+		for i, c := range b.Cells {
+			grid[c.Y][c.X] = b.Glyphs[i]
+		}
+	}
+	for _, r := range w.robots {
+		grid[r.Y][r.X] = '@'
+	}
+	lines := make([]string, len(grid))
+	for y, row := range grid {
+		lines[y] = string(row)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func dirName(d Point) string {
+	switch d {
+	case Point{0, -1}:
+		return "^"
+	case Point{0, 1}:
+		return "v"
+	case Point{-1, 0}:
+		return "<"
+	case Point{1, 0}:
+		return ">"
+	}
+	return "?"
+}
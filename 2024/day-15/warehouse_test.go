@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestMoveDiscardsRedo(t *testing.T) {
+	w, err := NewWarehouse([]string{
+		"#####",
+		"#@O.#",
+		"#####",
+	}, normalConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	right, left := Point{1, 0}, Point{-1, 0}
+	if !w.Move(0, right) {
+		t.Fatal("first push was blocked")
+	}
+	if w.Undo(1) != 1 {
+		t.Fatal("Undo did not revert the push")
+	}
+	// Back at the start the robot cannot step left into the wall.
+	if w.Move(0, left) {
+		t.Fatal("robot walked into the wall")
+	}
+	if n := w.Replay(1); n != 0 {
+		t.Errorf("Replay after a blocked move replayed %d, want 0", n)
+	}
+	if got := w.GPS(); got != 102 {
+		t.Errorf("GPS = %d, want the box back at its start (102)", got)
+	}
+}
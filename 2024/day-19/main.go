@@ -2,12 +2,20 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"math/big"
+	"math/rand"
 	"os"
 	"strings"
 )
 
 func main() {
+	show := flag.Int("show", -1, "index of a design whose decompositions to print")
+	limit := flag.Int("limit", 10, "most decompositions to print with -show")
+	seed := flag.Int64("seed", 1, "random seed for the sampled decomposition")
+	flag.Parse()
+
 	file, err := os.Open("input.txt")
 	if err != nil {
 		panic(err)
@@ -28,44 +36,33 @@ func main() {
 		designs = append(designs, strings.TrimSpace(scanner.Text()))
 	}
 
+	towels := NewTowels(patterns)
 	possible := 0
 	for _, design := range designs {
-		if countWays(design, patterns) > 0 {
+		if towels.Count(design).Sign() > 0 {
 			possible++
 		}
 	}
 	fmt.Printf("Possible designs: %d\n", possible)
 
-	totalWays := 0
+	totalWays := new(big.Int)
 	for _, design := range designs {
-		ways := countWays(design, patterns)
-		totalWays += ways
+		ways := towels.Count(design)
+		totalWays.Add(totalWays, ways)
 	}
 	fmt.Printf("Total Ways: %d\n", totalWays)
-}
-
-func countWays(design string, patterns []string) int {
-	memo := make(map[string]int)
-	return countWaysMemo(design, patterns, memo)
-}
-
-func countWaysMemo(design string, patterns []string, memo map[string]int) int {
-	if design == "" {
-		return 1
-	}
-
-	if result, exists := memo[design]; exists {
-		return result
-	}
 
-	ways := 0
-	for _, pattern := range patterns {
-		if strings.HasPrefix(design, pattern) {
-			remaining := design[len(pattern):]
-			ways += countWaysMemo(remaining, patterns, memo)
+	if *show >= 0 && *show < len(designs) {
+		design := designs[*show]
+		fmt.Printf("\nDesign %s: %d ways\n", design, towels.Count(design))
+		for _, pieces := range towels.Enumerate(design, *limit) {
+			fmt.Println(" ", strings.Join(pieces, " "))
+		}
+		sample, err := towels.Sample(design, rand.New(rand.NewSource(*seed)))
+		if err != nil {
+			fmt.Println("Random:", err)
+		} else {
+			fmt.Println("Random:", strings.Join(sample, " "))
 		}
 	}
-
-	memo[design] = ways
-	return ways
 }
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+	"math/bits"
+	"math/rand"
+)
+
+// node is a trie node extended into an Aho–Corasick automaton. next holds
+// one goto target per alphabet symbol, with fail transitions already
+// folded in, so matching never backtracks.
+type node struct {
+	next  []int32
+	fail  int32
+	depth int
+	// terminal marks a node that spells a whole pattern; output is the
+	// nearest terminal proper suffix, forming the chain of every pattern
+	// that ends at the same place.
+	terminal bool
+	output   int32
+}
+
+// Towels matches designs against a set of patterns. Its alphabet is only
+// the bytes the patterns use, which keeps nodes small however many
+// patterns there are.
+type Towels struct {
+	nodes    []node
+	symbol   [256]int16
+	alphabet int
+}
+
+func NewTowels(patterns []string) *Towels {
+	t := &Towels{}
+	for i := range t.symbol {
+		t.symbol[i] = -1
+	}
+	for _, p := range patterns {
+		for i := range len(p) {
+			if t.symbol[p[i]] < 0 {
+				t.symbol[p[i]] = int16(t.alphabet)
+				t.alphabet++
+			}
+		}
+	}
+	t.nodes = []node{t.newNode(0)}
+	for _, p := range patterns {
+		if p == "" {
+			continue
+		}
+		cur := int32(0)
+		for i := range len(p) {
+			s := t.symbol[p[i]]
+			if t.nodes[cur].next[s] < 0 {
+				t.nodes[cur].next[s] = int32(len(t.nodes))
+				t.nodes = append(t.nodes, t.newNode(t.nodes[cur].depth+1))
+			}
+			cur = t.nodes[cur].next[s]
+		}
+		t.nodes[cur].terminal = true
+	}
+
+	// Breadth-first, so every node's fail target is finished before it.
+	queue := []int32{}
+	for s := range t.alphabet {
+		if child := t.nodes[0].next[s]; child >= 0 {
+			queue = append(queue, child)
+		} else {
+			t.nodes[0].next[s] = 0
+		}
+	}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		f := t.nodes[u].fail
+		if t.nodes[f].terminal {
+			t.nodes[u].output = f
+		} else {
+			t.nodes[u].output = t.nodes[f].output
+		}
+		for s := range t.alphabet {
+			child := t.nodes[u].next[s]
+			if child < 0 {
+				t.nodes[u].next[s] = t.nodes[f].next[s]
+				continue
+			}
+			if u != 0 {
+				t.nodes[child].fail = t.nodes[f].next[s]
+			}
+			queue = append(queue, child)
+		}
+	}
+	return t
+}
+
+func (t *Towels) newNode(depth int) node {
+	n := node{next: make([]int32, t.alphabet), depth: depth, output: -1}
+	for i := range n.next {
+		n.next[i] = -1
+	}
+	return n
+}
+
+// starts lists, for each index of design, the lengths of the patterns
+// that begin there, from one left-to-right pass of the automaton.
+func (t *Towels) starts(design string) [][]int {
+	starts := make([][]int, len(design))
+	cur := int32(0)
+	for j := range len(design) {
+		s := t.symbol[design[j]]
+		if s < 0 {
+			cur = 0
+			continue
+		}
+		cur = t.nodes[cur].next[s]
+		m := cur
+		if !t.nodes[m].terminal {
+			m = t.nodes[m].output
+		}
+		for ; m > 0; m = t.nodes[m].output {
+			d := t.nodes[m].depth
+			starts[j+1-d] = append(starts[j+1-d], d)
+		}
+	}
+	return starts
+}
+
+// counts holds, for each index i, how many ways design[i:] splits into
+// patterns. They stay in small while every one fits in a uint64; the
+// number of ways grows exponentially with the design's length, so a long
+// design moves them all to large.
+type counts struct {
+	small []uint64
+	large []*big.Int
+}
+
+func (c counts) zero(i int) bool {
+	if c.large != nil {
+		return c.large[i].Sign() == 0
+	}
+	return c.small[i] == 0
+}
+
+func (c counts) at(i int) *big.Int {
+	if c.large != nil {
+		return c.large[i]
+	}
+	return new(big.Int).SetUint64(c.small[i])
+}
+
+// ways fills in the counts from the end of design.
+func (t *Towels) ways(design string) (counts, [][]int) {
+	starts := t.starts(design)
+	small := make([]uint64, len(design)+1)
+	small[len(design)] = 1
+	for i := len(design) - 1; i >= 0; i-- {
+		for _, l := range starts[i] {
+			var carry uint64
+			if small[i], carry = bits.Add64(small[i], small[i+l], 0); carry != 0 {
+				return t.bigWays(design, starts), starts
+			}
+		}
+	}
+	return counts{small: small}, starts
+}
+
+func (t *Towels) bigWays(design string, starts [][]int) counts {
+	large := make([]*big.Int, len(design)+1)
+	large[len(design)] = big.NewInt(1)
+	for i := len(design) - 1; i >= 0; i-- {
+		large[i] = new(big.Int)
+		for _, l := range starts[i] {
+			large[i].Add(large[i], large[i+l])
+		}
+	}
+	return counts{large: large}
+}
+
+// Count is the number of ways to build design from the patterns.
+func (t *Towels) Count(design string) *big.Int {
+	ways, _ := t.ways(design)
+	return ways.at(0)
+}
+
+// Enumerate returns up to limit decompositions of design. Dead ends are
+// pruned with the DP table, so every branch taken yields at least one.
+func (t *Towels) Enumerate(design string, limit int) [][]string {
+	ways, starts := t.ways(design)
+	var out [][]string
+	var pieces []string
+	var walk func(i int)
+	walk = func(i int) {
+		if len(out) >= limit {
+			return
+		}
+		if i == len(design) {
+			out = append(out, append([]string(nil), pieces...))
+			return
+		}
+		for _, l := range starts[i] {
+			if ways.zero(i + l) {
+				continue
+			}
+			pieces = append(pieces, design[i:i+l])
+			walk(i + l)
+			pieces = pieces[:len(pieces)-1]
+		}
+	}
+	walk(0)
+	return out
+}
+
+var ErrImpossible = errors.New("design cannot be made from the patterns")
+
+// Sample picks one decomposition uniformly at random: each piece is chosen
+// with probability proportional to the number of ways to finish from after
+// it.
+func (t *Towels) Sample(design string, rng *rand.Rand) ([]string, error) {
+	ways, starts := t.ways(design)
+	if ways.zero(0) {
+		return nil, ErrImpossible
+	}
+	var pieces []string
+	for i := 0; i < len(design); {
+		r := new(big.Int).Rand(rng, ways.at(i))
+		for _, l := range starts[i] {
+			next := ways.at(i + l)
+			if r.Cmp(next) < 0 {
+				pieces = append(pieces, design[i:i+l])
+				i += l
+				break
+			}
+			r.Sub(r, next)
+		}
+	}
+	return pieces, nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var examplePatterns = []string{"r", "wr", "b", "g", "bwu", "rb", "gb", "br"}
+
+var exampleWays = map[string]int64{
+	"brwrr":  2,
+	"bggr":   1,
+	"gbbr":   4,
+	"rrbgbr": 6,
+	"ubwu":   0,
+	"bwurrg": 1,
+	"brgr":   2,
+	"bbrgwb": 0,
+}
+
+func TestCountExample(t *testing.T) {
+	towels := NewTowels(examplePatterns)
+	for design, want := range exampleWays {
+		if got := towels.Count(design); got.Cmp(big.NewInt(want)) != 0 {
+			t.Errorf("Count(%s) = %s, want %d", design, got, want)
+		}
+	}
+}
+
+// naiveCount tries every pattern as a prefix, as the original solution did.
+func naiveCount(design string, patterns []string) int64 {
+	if design == "" {
+		return 1
+	}
+	var n int64
+	for _, p := range patterns {
+		if p != "" && strings.HasPrefix(design, p) {
+			n += naiveCount(design[len(p):], patterns)
+		}
+	}
+	return n
+}
+
+func TestCountMatchesNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	word := func(n int) string {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = "abc"[rng.Intn(3)]
+		}
+		return string(b)
+	}
+	for range 300 {
+		var patterns []string
+		for range 1 + rng.Intn(8) {
+			// Patterns are a set: a repeated one adds no new ways.
+			if p := word(1 + rng.Intn(3)); !slices.Contains(patterns, p) {
+				patterns = append(patterns, p)
+			}
+		}
+		towels := NewTowels(patterns)
+		design := word(rng.Intn(14))
+		if got, want := towels.Count(design), naiveCount(design, patterns); got.Cmp(big.NewInt(want)) != 0 {
+			t.Fatalf("patterns %v, design %s: Count = %s, want %d", patterns, design, got, want)
+		}
+	}
+}
+
+func TestLongDesign(t *testing.T) {
+	// The ways to tile n cells with lengths 1 and 2 are Fibonacci numbers,
+	// far beyond 64 bits for n = 100.
+	towels := NewTowels([]string{"a", "aa"})
+	design := strings.Repeat("a", 100)
+	want, _ := new(big.Int).SetString("573147844013817084101", 10)
+	if got := towels.Count(design); got.Cmp(want) != 0 {
+		t.Errorf("Count = %s, want %s", got, want)
+	}
+
+	rng := rand.New(rand.NewSource(7))
+	for range 20 {
+		pieces, err := towels.Sample(design, rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(pieces, "") != design {
+			t.Fatalf("sample %v does not spell the design", pieces)
+		}
+	}
+	if got := towels.Enumerate(design, 3); len(got) != 3 {
+		t.Errorf("Enumerate gave %d decompositions, want 3", len(got))
+	}
+}
+
+func TestEnumerate(t *testing.T) {
+	towels := NewTowels(examplePatterns)
+	var got []string
+	for _, pieces := range towels.Enumerate("gbbr", 10) {
+		got = append(got, strings.Join(pieces, " "))
+	}
+	slices.Sort(got)
+	want := []string{"g b b r", "g b br", "gb b r", "gb br"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Enumerate(gbbr) = %q, want %q", got, want)
+	}
+	if got := towels.Enumerate("gbbr", 2); len(got) != 2 {
+		t.Errorf("Enumerate with limit 2 gave %d", len(got))
+	}
+	if got := towels.Enumerate("ubwu", 10); len(got) != 0 {
+		t.Errorf("Enumerate(ubwu) = %v, want none", got)
+	}
+}
+
+func TestSample(t *testing.T) {
+	towels := NewTowels(examplePatterns)
+	if _, err := towels.Sample("ubwu", rand.New(rand.NewSource(1))); !errors.Is(err, ErrImpossible) {
+		t.Errorf("Sample(ubwu) error = %v, want ErrImpossible", err)
+	}
+
+	// All four decompositions of gbbr should turn up, roughly equally.
+	rng := rand.New(rand.NewSource(2))
+	seen := make(map[string]int)
+	for range 4000 {
+		pieces, err := towels.Sample("gbbr", rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[strings.Join(pieces, " ")]++
+	}
+	if len(seen) != 4 {
+		t.Fatalf("samples %v, want all 4 decompositions", seen)
+	}
+	for d, n := range seen {
+		if n < 800 || n > 1200 {
+			t.Errorf("decomposition %q drawn %d times of 4000", d, n)
+		}
+	}
+}
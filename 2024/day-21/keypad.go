@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"aoc/memo"
+)
+
+// Keypad is a grid of keys; gaps in the layout are cells a robot arm must
+// never hover over.
+type Keypad struct {
+	keys  map[rune]Point
+	at    map[Point]rune
+	paths map[[2]rune][]string
+}
+
+var moves = []struct {
+	dir   rune
+	delta Point
+}{
+	{'^', Point{-1, 0}}, {'v', Point{1, 0}},
+	{'<', Point{0, -1}}, {'>', Point{0, 1}},
+}
+
+// ParseKeypad reads a layout with one row per line; spaces are gaps.
+// Blank lines are ignored so layouts can be written as raw strings.
+func ParseKeypad(layout string) (*Keypad, error) {
+	k := &Keypad{
+		keys:  make(map[rune]Point),
+		at:    make(map[Point]rune),
+		paths: make(map[[2]rune][]string),
+	}
+	row := 0
+	for _, line := range strings.Split(layout, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		for col, key := range []rune(line) {
+			if key == ' ' {
+				continue
+			}
+			if _, dup := k.keys[key]; dup {
+				return nil, fmt.Errorf("key %c appears twice", key)
+			}
+			k.keys[key] = Point{row, col}
+			k.at[Point{row, col}] = key
+		}
+		row++
+	}
+	if _, ok := k.keys['A']; !ok {
+		return nil, fmt.Errorf("keypad has no A key to start on")
+	}
+	return k, nil
+}
+
+func (k *Keypad) isDirectional() bool {
+	for _, key := range "^v<>A" {
+		if _, ok := k.keys[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// shortestPaths returns every shortest move sequence from one key to
+// another that never crosses a gap, each followed by the A press.
+func (k *Keypad) shortestPaths(from, to rune) []string {
+	if paths, ok := k.paths[[2]rune{from, to}]; ok {
+		return paths
+	}
+
+	start, target := k.keys[from], k.keys[to]
+	dist := map[Point]int{target: 0}
+	queue := []Point{target}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, m := range moves {
+			next := Point{p.x + m.delta.x, p.y + m.delta.y}
+			if _, ok := k.at[next]; !ok {
+				continue
+			}
+			if _, seen := dist[next]; !seen {
+				dist[next] = dist[p] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	var paths []string
+	var walk func(p Point, path []rune)
+	walk = func(p Point, path []rune) {
+		if p == target {
+			paths = append(paths, string(path)+"A")
+			return
+		}
+		for _, m := range moves {
+			next := Point{p.x + m.delta.x, p.y + m.delta.y}
+			if d, ok := dist[next]; ok && d == dist[p]-1 {
+				walk(next, append(path, m.dir))
+			}
+		}
+	}
+	if _, ok := dist[start]; ok {
+		walk(start, nil)
+	}
+	k.paths[[2]rune{from, to}] = paths
+	return paths
+}
+
+type memoKey struct {
+	level    int
+	from, to rune
+}
+
+type memoEntry struct {
+	cost int
+	path string
+}
+
+// Solver finds the fewest human presses to type a code through a chain of
+// keypads. chain[0] is the keypad the code is typed on; each later keypad
+// drives the robot arm on the one before it, and the human presses the
+// last one directly.
+type Solver struct {
+	chain []*Keypad
+	memo  *memo.Cache[memoKey, memoEntry]
+}
+
+func NewSolver(chain ...*Keypad) (*Solver, error) {
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("empty keypad chain")
+	}
+	for i, k := range chain[1:] {
+		if !k.isDirectional() {
+			return nil, fmt.Errorf("keypad %d drives a robot but lacks ^v<>A", i+1)
+		}
+	}
+	return &Solver{chain: chain, memo: memo.NewCache[memoKey, memoEntry]()}, nil
+}
+
+// press is the cheapest way to move from one key to another on
+// chain[level] and press it, with the path on that keypad that achieves it.
+func (s *Solver) press(level int, from, to rune) (memoEntry, error) {
+	key := memoKey{level, from, to}
+	if e, ok := s.memo.Get(key); ok {
+		return e, nil
+	}
+
+	if _, ok := s.chain[level].keys[to]; !ok {
+		return memoEntry{}, fmt.Errorf("keypad %d has no key %c", level, to)
+	}
+	best := memoEntry{cost: -1}
+	for _, path := range s.chain[level].shortestPaths(from, to) {
+		cost, err := s.cost(level+1, path)
+		if err != nil {
+			return memoEntry{}, err
+		}
+		if best.cost == -1 || cost < best.cost {
+			best = memoEntry{cost, path}
+		}
+	}
+	if best.cost == -1 {
+		return memoEntry{}, fmt.Errorf("keypad %d: %c unreachable from %c", level, to, from)
+	}
+	s.memo.Put(key, best)
+	return best, nil
+}
+
+// cost is the number of human presses needed to type seq on chain[level].
+func (s *Solver) cost(level int, seq string) (int, error) {
+	if level == len(s.chain) {
+		return len(seq), nil
+	}
+	total, current := 0, 'A'
+	for _, char := range seq {
+		e, err := s.press(level, current, char)
+		if err != nil {
+			return 0, err
+		}
+		total += e.cost
+		current = char
+	}
+	return total, nil
+}
+
+// Stats reports how the solver's memo has been used.
+func (s *Solver) Stats() memo.Stats { return s.memo.Stats() }
+
+func (s *Solver) Length(code string) (int, error) {
+	return s.cost(0, code)
+}
+
+// Sequence returns an optimal series of human presses for code. It fails
+// rather than build a sequence longer than limit.
+func (s *Solver) Sequence(code string, limit int) (string, error) {
+	n, err := s.Length(code)
+	if err != nil {
+		return "", err
+	}
+	if n > limit {
+		return "", fmt.Errorf("sequence for %s has %d presses, over the limit of %d", code, n, limit)
+	}
+
+	// press reads the memo and recomputes anything it no longer holds, so
+	// this works whether or not the cache is bounded.
+	var sb strings.Builder
+	var expand func(level int, seq string) error
+	expand = func(level int, seq string) error {
+		if level == len(s.chain) {
+			sb.WriteString(seq)
+			return nil
+		}
+		current := 'A'
+		for _, char := range seq {
+			e, err := s.press(level, current, char)
+			if err != nil {
+				return err
+			}
+			if err := expand(level+1, e.path); err != nil {
+				return err
+			}
+			current = char
+		}
+		return nil
+	}
+	if err := expand(0, code); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"aoc/memo"
+)
+
+var exampleLengths = map[string]int{"029A": 68, "980A": 60, "179A": 68, "456A": 64, "379A": 64}
+
+func TestLength(t *testing.T) {
+	s := buildSolver(2)
+	for code, want := range exampleLengths {
+		if got, err := s.Length(code); err != nil || got != want {
+			t.Errorf("Length(%s) = %d, %v, want %d", code, got, err, want)
+		}
+	}
+}
+
+func TestSequenceWithBoundedMemo(t *testing.T) {
+	// A tiny LRU evicts most entries before Sequence reads them back.
+	s := buildSolver(2)
+	s.memo = memo.NewLRU[memoKey, memoEntry](2)
+	for code, want := range exampleLengths {
+		seq, err := s.Sequence(code, 1<<12)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(seq) != want {
+			t.Errorf("Sequence(%s) has %d presses, want %d", code, len(seq), want)
+		}
+		if got := replay(t, s, seq); got != code {
+			t.Errorf("Sequence(%s) types %q", code, got)
+		}
+	}
+}
+
+// replay runs human presses down the chain and returns what is typed on
+// the first keypad.
+func replay(t *testing.T, s *Solver, seq string) string {
+	t.Helper()
+	for level := len(s.chain) - 1; level >= 0; level-- {
+		k := s.chain[level]
+		pos := k.keys['A']
+		var typed []rune
+		for _, c := range seq {
+			if c == 'A' {
+				typed = append(typed, k.at[pos])
+				continue
+			}
+			for _, m := range moves {
+				if m.dir == c {
+					pos = Point{pos.x + m.delta.x, pos.y + m.delta.y}
+				}
+			}
+			if _, ok := k.at[pos]; !ok {
+				t.Fatalf("arm on keypad %d hovers over a gap at %v", level, pos)
+			}
+		}
+		seq = string(typed)
+	}
+	return seq
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 )
@@ -10,166 +12,75 @@ type Point struct {
 	x, y int
 }
 
-var numericKeypad = map[Point]rune{
-	{0, 0}: '7', {0, 1}: '8', {0, 2}: '9',
-	{1, 0}: '4', {1, 1}: '5', {1, 2}: '6',
-	{2, 0}: '1', {2, 1}: '2', {2, 2}: '3',
-	{3, 1}: '0', {3, 2}: 'A',
-}
-
-var directionalKeypad = map[Point]rune{
-	{0, 1}: '^', {0, 2}: 'A',
-	{1, 0}: '<', {1, 1}: 'v', {1, 2}: '>',
-}
-
-var memo = make(map[string]int)
-
-func getPosition(keypad map[Point]rune, key rune) Point {
-	for pos, k := range keypad {
-		if k == key {
-			return pos
-		}
+const numericLayout = `
+789
+456
+123
+ 0A
+`
+
+const directionalLayout = `
+ ^A
+<v>
+`
+
+func buildSolver(directionalLevels int) *Solver {
+	numeric, err := ParseKeypad(numericLayout)
+	if err != nil {
+		panic(err)
 	}
-	panic(fmt.Sprintf("Key %c not found", key))
-}
-
-// Find the shortest path between two keys, returning the sequence
-func findShortestPath(keypad map[Point]rune, from, to rune) []string {
-	if from == to {
-		return []string{"A"}
+	directional, err := ParseKeypad(directionalLayout)
+	if err != nil {
+		panic(err)
 	}
 
-	start := getPosition(keypad, from)
-	target := getPosition(keypad, to)
-
-	type state struct {
-		pos  Point
-		path string
+	chain := []*Keypad{numeric}
+	for range directionalLevels {
+		chain = append(chain, directional)
 	}
-	queue := []state{{start, ""}}
-	visited := make(map[Point]int)
-	var allPaths []string
-	minLength := -1
-	directions := map[rune]Point{
-		'^': {-1, 0}, 'v': {1, 0},
-		'<': {0, -1}, '>': {0, 1},
+	solver, err := NewSolver(chain...)
+	if err != nil {
+		panic(err)
 	}
-
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = queue[1:]
-
-		if minLength != -1 && len(current.path) > minLength {
-			break
-		}
-
-		if current.pos == target {
-			if minLength == -1 {
-				minLength = len(current.path)
-			}
-			allPaths = append(allPaths, current.path+"A")
-			continue
-		}
-		if prev, exists := visited[current.pos]; exists && prev < len(current.path) {
-			continue
-		}
-		visited[current.pos] = len(current.path)
-
-		for dirChar, delta := range directions {
-			newPos := Point{current.pos.x + delta.x, current.pos.y + delta.y}
-			if _, exists := keypad[newPos]; exists {
-				queue = append(queue, state{newPos, current.path + string(dirChar)})
-			}
-		}
-	}
-	return allPaths
-}
-
-func isValidPath(keypad map[Point]rune, start Point, path string) bool {
-	pos := start
-	directions := map[rune]Point{
-		'^': {-1, 0}, 'v': {1, 0},
-		'<': {0, -1}, '>': {0, 1},
-	}
-
-	for _, dir := range path {
-		if delta, ok := directions[dir]; ok {
-			pos = Point{pos.x + delta.x, pos.y + delta.y}
-			if _, exists := keypad[pos]; !exists {
-				return false
-			}
-		}
-	}
-	return true
+	return solver
 }
 
-func findMinLength(sequence string, depth int) int {
-	if depth == 0 {
-		return len(sequence)
-	}
-
-	key := fmt.Sprintf("%s-%d", sequence, depth)
-	if result, exists := memo[key]; exists {
-		return result
-	}
-
+func totalComplexity(codes []string, solver *Solver, show bool) int {
 	total := 0
-	current := 'A'
-
-	for _, char := range sequence {
-		allPaths := findShortestPath(directionalKeypad, current, char)
-		minCost := int(^uint(0) >> 1)
-
-		for _, path := range allPaths {
-			cost := findMinLength(path, depth-1)
-			if cost < minCost {
-				minCost = cost
-			}
+	for _, code := range codes {
+		length, err := solver.Length(code)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
 		}
-		total += minCost
-		current = char
-	}
-
-	memo[key] = total
-	return total
-}
-
-func solveCode(code string, directionalLevels int) int {
-	total := 0
-	current := 'A'
-
-	for _, char := range code {
-		allPaths := findShortestPath(numericKeypad, current, char)
-		minCost := int(^uint(0) >> 1)
+		numericPart := strings.TrimSuffix(code, "A")
+		numeric, _ := strconv.Atoi(numericPart)
+		complexity := length * numeric
 
-		for _, path := range allPaths {
-			cost := findMinLength(path, directionalLevels)
-			if cost < minCost {
-				minCost = cost
+		fmt.Printf("Code %s: length=%d, numeric=%d, complexity=%d\n",
+			code, length, numeric, complexity)
+		if show {
+			if seq, err := solver.Sequence(code, 1<<12); err == nil {
+				fmt.Println("  ", seq)
 			}
 		}
-		total += minCost
-		current = char
+		total += complexity
 	}
 	return total
 }
 
 func main() {
-	codes := []string{"805A", "964A", "459A", "968A", "671A"}
-	totalComplexity := 0
+	show := flag.Bool("show", false, "print the optimal button sequence when it is short enough")
+	stats := flag.Bool("stats", false, "print memo cache statistics")
+	flag.Parse()
 
-	for _, code := range codes {
-		// INFO: For part 2 change the directionalLevels to 25, without memo this would
-		// have been impossible
-		length := solveCode(code, 25)
-		numericPart := strings.TrimSuffix(code, "A")
-		numeric, _ := strconv.Atoi(numericPart)
-		complexity := length * numeric
+	codes := []string{"805A", "964A", "459A", "968A", "671A"}
 
-		fmt.Printf("Code %s: length=%d, numeric=%d, complexity=%d\n",
-			code, length, numeric, complexity)
-		totalComplexity += complexity
+	for part, levels := range []int{2, 25} {
+		solver := buildSolver(levels)
+		fmt.Printf("Part %d total complexity: %d\n", part+1, totalComplexity(codes, solver, *show))
+		if *stats {
+			fmt.Println("Cache:", solver.Stats())
+		}
 	}
-
-	fmt.Printf("Total complexity: %d\n", totalComplexity)
 }
@@ -2,10 +2,15 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"flag"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+
+	"aoc/parallel"
 )
 
 func mix(secret, value int) int {
@@ -32,22 +37,35 @@ func nextSecret(secret int) int {
 	return secret
 }
 
+// generateNthSecret jumps straight to the nth secret; the jump matrix for
+// each n is built once and shared. The matrix only sees the low 24 bits,
+// which are all the first step reads, so initial is pruned before the jump.
 func generateNthSecret(initial, n int) int {
-	current := initial
-	for range n {
-		current = nextSecret(current)
+	if n == 0 {
+		return initial
+	}
+	m, ok := jumps.Load(n)
+	if !ok {
+		m, _ = jumps.LoadOrStore(n, jumpMatrix(n))
 	}
-	return current
+	return m.(*gf2Matrix).apply(prune(initial))
 }
 
-func solvePart1(initials []int) int {
+var jumps sync.Map
+
+func solvePart1(ctx context.Context, opts parallel.Options, initials []int) (int, error) {
+	secrets, err := parallel.Map(ctx, opts, initials, func(initial int) int {
+		return generateNthSecret(initial, 2000)
+	})
+	if err != nil {
+		return 0, err
+	}
 	totalSum := 0
-	for _, initial := range initials {
-		secret2000 := generateNthSecret(initial, 2000)
-		fmt.Printf("Initial: %d, 2000th: %d\n", initial, secret2000)
+	for i, secret2000 := range secrets {
+		fmt.Printf("Initial: %d, 2000th: %d\n", initials[i], secret2000)
 		totalSum += secret2000
 	}
-	return totalSum
+	return totalSum, nil
 }
 
 func parseInputFile(filename string) ([]int, error) {
@@ -74,81 +92,52 @@ func parseInputFile(filename string) ([]int, error) {
 	return numbers, nil
 }
 
-func generatePricesAndChanges(initial, count int) ([]int, []int) {
-	prices := make([]int, count+1)
-	prices[0] = initial % 10
-
-	current := initial
-	for i := 1; i <= count; i++ {
-		current = nextSecret(current)
-		prices[i] = current % 10
-	}
-	changes := make([]int, count)
-	for i := range count {
-		changes[i] = prices[i+1] - prices[i]
-	}
-	return prices, changes
-}
-
-func findFirstOccurrence(changes, prices []int, sequence [4]int) int {
-	if len(changes) < 4 {
-		return 0
-	}
-	for i := 0; i <= len(changes)-4; i++ {
-		if changes[i] == sequence[0] &&
-			changes[i+1] == sequence[1] &&
-			changes[i+2] == sequence[2] &&
-			changes[i+3] == sequence[3] {
-			return prices[i+4]
-		}
+func solvePart2(ctx context.Context, opts parallel.Options, initials []int, top int) (int, error) {
+	totals, err := bananaTotals(ctx, opts, initials, 2000)
+	if err != nil {
+		return 0, err
 	}
-	return 0
-}
-
-func solvePart2(initials []int) int {
-	sequenceTotals := make(map[[4]int]int)
-	for buyerIdx, initial := range initials {
-		if buyerIdx%100 == 0 {
-			fmt.Printf("Processing buyer %d/%d...\n", buyerIdx+1, len(initials))
-		}
-		prices, changes := generatePricesAndChanges(initial, 2000)
-		foundSequences := make(map[[4]int]bool)
-
-		for i := 0; i <= len(changes)-4; i++ {
-			sequence := [4]int{changes[i], changes[i+1], changes[i+2], changes[i+3]}
 
-			if !foundSequences[sequence] {
-				foundSequences[sequence] = true
-				price := prices[i+4]
-				sequenceTotals[sequence] += price
+	sales := topSequences(totals, initials, 2000, max(top, 1))
+	for i, sale := range sales[:min(top, len(sales))] {
+		buyers, best, bestBuyer := 0, 0, 0
+		for buyer, price := range sale.PerBuyer {
+			if price > 0 {
+				buyers++
+			}
+			if price > best {
+				best, bestBuyer = price, buyer
 			}
 		}
+		fmt.Printf("%d. sequence %v gives %d bananas from %d paying buyers (best: buyer %d pays %d)\n",
+			i+1, sale.Sequence, sale.Total, buyers, bestBuyer, best)
 	}
-
-	maxBananas := 0
-	var bestSequence [4]int
-
-	for sequence, total := range sequenceTotals {
-		if total > maxBananas {
-			maxBananas = total
-			bestSequence = sequence
-		}
-	}
-	fmt.Printf("Best sequence: [%d,%d,%d,%d] gives %d bananas\n",
-		bestSequence[0], bestSequence[1], bestSequence[2], bestSequence[3], maxBananas)
-
-	return maxBananas
+	return sales[0].Total, nil
 }
 
 func main() {
+	workers := flag.Int("workers", 0, "parallel workers (0 = one per CPU)")
+	top := flag.Int("top", 1, "show the best k sequences with their buyer breakdown")
+	flag.Parse()
+
 	puzzleInput, err := parseInputFile("input.txt")
 	if err != nil {
 		return
 	}
+	ctx := context.Background()
+	opts := parallel.Options{Workers: *workers}
 
-	result1 := solvePart1(puzzleInput)
+	result1, err := solvePart1(ctx, opts, puzzleInput)
+	if err != nil {
+		fmt.Println("Part 1:", err)
+		return
+	}
 	fmt.Printf("Part 1 answer :%d\n", result1)
 
-	result2 := solvePart2(puzzleInput)
+	result2, err := solvePart2(ctx, opts, puzzleInput, *top)
+	if err != nil {
+		fmt.Println("Part 2:", err)
+		return
+	}
 	fmt.Printf("Part 2 answer :%d\n", result2)
 }
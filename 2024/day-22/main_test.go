@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+func TestGenerateNthSecret(t *testing.T) {
+	for _, initial := range []int{0, 1, 123, 2024, 1<<24 - 1, 1 << 24, 1<<24 + 123, 1<<40 + 2024} {
+		want := initial
+		for n := range 50 {
+			if got := generateNthSecret(initial, n); got != want {
+				t.Errorf("generateNthSecret(%d, %d) = %d, want %d", initial, n, got, want)
+			}
+			want = nextSecret(want)
+		}
+	}
+	if got := generateNthSecret(123, 10); got != 5908254 {
+		t.Errorf("tenth secret after 123 = %d, want 5908254", got)
+	}
+}
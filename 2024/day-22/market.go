@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"iter"
+	"sort"
+
+	"aoc/parallel"
+)
+
+// Secrets yields the secret numbers that follow seed, forever.
+func Secrets(seed int) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for s := nextSecret(seed); yield(s); s = nextSecret(s) {
+		}
+	}
+}
+
+// secretBits is the width of a secret after pruning.
+const secretBits = 24
+
+// gf2Matrix is a linear map on 24-bit secrets over GF(2): column i is the
+// image of bit i. Every step of nextSecret is a shift, an xor or a mask
+// to 24 bits, so one step is such a map and n steps are its nth power.
+type gf2Matrix [secretBits]uint32
+
+func (m *gf2Matrix) apply(x int) int {
+	var out uint32
+	for i := range secretBits {
+		if x&(1<<i) != 0 {
+			out ^= m[i]
+		}
+	}
+	return int(out)
+}
+
+// then returns the map that applies m and then n.
+func (m *gf2Matrix) then(n *gf2Matrix) *gf2Matrix {
+	var out gf2Matrix
+	for i := range secretBits {
+		out[i] = uint32(n.apply(int(m[i])))
+	}
+	return &out
+}
+
+// jumpMatrix is nextSecret applied n times, built by repeated squaring.
+func jumpMatrix(n int) *gf2Matrix {
+	var step, result gf2Matrix
+	for i := range secretBits {
+		step[i] = uint32(nextSecret(1 << i))
+		result[i] = 1 << i
+	}
+	r, s := &result, &step
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			r = r.then(s)
+		}
+		s = s.then(s)
+	}
+	return r
+}
+
+// Changes are kept as one base-19 number per window of four, each change
+// shifted from [-9, 9] to [0, 18], so counts live in a flat array.
+const sequenceSpace = 19 * 19 * 19 * 19
+
+func encodeSequence(s [4]int) int {
+	return (((s[0]+9)*19+s[1]+9)*19+s[2]+9)*19 + s[3] + 9
+}
+
+func decodeSequence(key int) [4]int {
+	var s [4]int
+	for i := 3; i >= 0; i-- {
+		s[i] = key%19 - 9
+		key /= 19
+	}
+	return s
+}
+
+// firstSales calls sell(key, price) for the first time each four-change
+// window appears in a buyer's next count prices.
+func firstSales(initial, count int, seen []int32, mark int32, sell func(key, price int)) {
+	prev, key, i := initial%10, 0, 0
+	for secret := range Secrets(initial) {
+		if i == count {
+			return
+		}
+		i++
+		price := secret % 10
+		key = (key*19 + price - prev + 9) % sequenceSpace
+		prev = price
+		if i >= 4 && seen[key] != mark {
+			seen[key] = mark
+			sell(key, price)
+		}
+	}
+}
+
+type market struct {
+	totals []int32
+	seen   []int32
+}
+
+func newMarket() *market {
+	return &market{make([]int32, sequenceSpace), make([]int32, sequenceSpace)}
+}
+
+// bananaTotals sums, for every sequence, what each buyer pays on its first
+// appearance, with buyers split across workers.
+func bananaTotals(ctx context.Context, opts parallel.Options, initials []int, count int) ([]int32, error) {
+	buyers := make([]int, len(initials))
+	for i := range buyers {
+		buyers[i] = i
+	}
+	m, err := parallel.Reduce(ctx, opts, buyers, newMarket,
+		func(m *market, buyer int) *market {
+			firstSales(initials[buyer], count, m.seen, int32(buyer+1), func(key, price int) {
+				m.totals[key] += int32(price)
+			})
+			return m
+		},
+		func(a, b *market) *market {
+			for key, total := range b.totals {
+				a.totals[key] += total
+			}
+			return a
+		})
+	if err != nil {
+		return nil, err
+	}
+	return m.totals, nil
+}
+
+// Sale is a sequence with its total and what each buyer paid for it, zero
+// for buyers whose prices never show it.
+type Sale struct {
+	Sequence [4]int
+	Total    int
+	PerBuyer []int
+}
+
+// topSequences returns the k best sequences, best first, each with its
+// per-buyer breakdown.
+func topSequences(totals []int32, initials []int, count, k int) []Sale {
+	keys := make([]int, len(totals))
+	for i := range keys {
+		keys[i] = i
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return totals[keys[i]] > totals[keys[j]] })
+	keys = keys[:min(k, len(keys))]
+
+	rank := make(map[int]int, len(keys))
+	sales := make([]Sale, len(keys))
+	for i, key := range keys {
+		rank[key] = i
+		sales[i] = Sale{decodeSequence(key), int(totals[key]), make([]int, len(initials))}
+	}
+	seen := make([]int32, sequenceSpace)
+	for buyer, initial := range initials {
+		firstSales(initial, count, seen, int32(buyer+1), func(key, price int) {
+			if i, ok := rank[key]; ok {
+				sales[i].PerBuyer[buyer] = price
+			}
+		})
+	}
+	return sales
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// bitset is a fixed-size set of node ids packed 64 to a word.
+type bitset []uint64
+
+func newBitset(n int) bitset { return make(bitset, (n+63)/64) }
+
+func (b bitset) set(i int)      { b[i/64] |= 1 << (i % 64) }
+func (b bitset) clear(i int)    { b[i/64] &^= 1 << (i % 64) }
+func (b bitset) has(i int) bool { return b[i/64]&(1<<(i%64)) != 0 }
+
+func (b bitset) clone() bitset { return append(bitset(nil), b...) }
+
+func (b bitset) and(o bitset) bitset {
+	out := make(bitset, len(b))
+	for i := range b {
+		out[i] = b[i] & o[i]
+	}
+	return out
+}
+
+func (b bitset) andNot(o bitset) bitset {
+	out := make(bitset, len(b))
+	for i := range b {
+		out[i] = b[i] &^ o[i]
+	}
+	return out
+}
+
+func (b bitset) count() int {
+	n := 0
+	for _, w := range b {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+func (b bitset) empty() bool {
+	for _, w := range b {
+		if w != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// each calls fn for every member in increasing order.
+func (b bitset) each(fn func(int)) {
+	for i, w := range b {
+		for w != 0 {
+			fn(i*64 + bits.TrailingZeros64(w))
+			w &= w - 1
+		}
+	}
+}
+
+// Graph is an undirected graph over named nodes with bitset adjacency.
+type Graph struct {
+	names []string
+	index map[string]int
+	adj   []bitset
+	nbrs  [][]int
+}
+
+// NewGraph builds a graph from undirected edges. Nodes are numbered in
+// sorted name order so results are deterministic.
+func NewGraph(edges [][2]string) *Graph {
+	g := &Graph{index: make(map[string]int)}
+	for _, e := range edges {
+		for _, name := range e {
+			if _, ok := g.index[name]; !ok {
+				g.index[name] = 0
+				g.names = append(g.names, name)
+			}
+		}
+	}
+	sort.Strings(g.names)
+	for i, name := range g.names {
+		g.index[name] = i
+	}
+
+	n := len(g.names)
+	g.adj = make([]bitset, n)
+	for i := range g.adj {
+		g.adj[i] = newBitset(n)
+	}
+	g.nbrs = make([][]int, n)
+	for _, e := range edges {
+		a, b := g.index[e[0]], g.index[e[1]]
+		if a == b || g.adj[a].has(b) {
+			continue
+		}
+		g.adj[a].set(b)
+		g.adj[b].set(a)
+		g.nbrs[a] = append(g.nbrs[a], b)
+		g.nbrs[b] = append(g.nbrs[b], a)
+	}
+	return g
+}
+
+func (g *Graph) Len() int { return len(g.names) }
+
+func (g *Graph) Name(id int) string { return g.names[id] }
+
+// Names returns the sorted names of the given node ids.
+func (g *Graph) Names(ids []int) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = g.names[id]
+	}
+	sort.Strings(out)
+	return out
+}
+
+// DegeneracyOrder repeatedly removes a node of minimum remaining degree.
+// It returns the removal order and the largest degree seen at removal.
+func (g *Graph) DegeneracyOrder() (order []int, degeneracy int) {
+	n := g.Len()
+	degree := make([]int, n)
+	maxDeg := 0
+	for i, nb := range g.nbrs {
+		degree[i] = len(nb)
+		maxDeg = max(maxDeg, degree[i])
+	}
+	buckets := make([][]int, maxDeg+1)
+	for i, d := range degree {
+		buckets[d] = append(buckets[d], i)
+	}
+
+	removed := make([]bool, n)
+	for d := 0; len(order) < n; {
+		if len(buckets[d]) == 0 {
+			d++
+			continue
+		}
+		v := buckets[d][len(buckets[d])-1]
+		buckets[d] = buckets[d][:len(buckets[d])-1]
+		if removed[v] || degree[v] != d {
+			continue
+		}
+		removed[v] = true
+		order = append(order, v)
+		degeneracy = max(degeneracy, d)
+		for _, u := range g.nbrs[v] {
+			if !removed[u] {
+				degree[u]--
+				buckets[degree[u]] = append(buckets[degree[u]], u)
+			}
+		}
+		d = max(d-1, 0)
+	}
+	return order, degeneracy
+}
+
+// MaximalCliques calls visit with every maximal clique, using Bron–Kerbosch
+// with pivoting under a degeneracy ordering. Returning false stops early.
+func (g *Graph) MaximalCliques(visit func(clique []int) bool) {
+	order, _ := g.DegeneracyOrder()
+	n := g.Len()
+
+	stopped := false
+	var r []int
+	var expand func(p, x bitset)
+	expand = func(p, x bitset) {
+		if p.empty() {
+			if x.empty() {
+				stopped = !visit(append([]int(nil), r...))
+			}
+			return
+		}
+
+		pivot, best := -1, -1
+		pick := func(u int) {
+			if c := g.adj[u].and(p).count(); c > best {
+				pivot, best = u, c
+			}
+		}
+		p.each(pick)
+		x.each(pick)
+
+		p.andNot(g.adj[pivot]).each(func(v int) {
+			if stopped {
+				return
+			}
+			r = append(r, v)
+			expand(p.and(g.adj[v]), x.and(g.adj[v]))
+			r = r[:len(r)-1]
+			p.clear(v)
+			x.set(v)
+		})
+	}
+
+	later := newBitset(n)
+	for _, v := range order {
+		later.set(v)
+	}
+	earlier := newBitset(n)
+	for _, v := range order {
+		if stopped {
+			return
+		}
+		later.clear(v)
+		r = append(r[:0], v)
+		expand(later.and(g.adj[v]), earlier.and(g.adj[v]))
+		earlier.set(v)
+	}
+}
+
+// MaximumClique returns the largest clique, ties broken by enumeration order.
+func (g *Graph) MaximumClique() []int {
+	var best []int
+	g.MaximalCliques(func(c []int) bool {
+		if len(c) > len(best) {
+			best = c
+		}
+		return true
+	})
+	return best
+}
+
+// CountCliques counts k-cliques for which keep returns true (keep may be
+// nil). Each clique is built only once, from the node earliest in the
+// degeneracy order, so candidates stay within a node's later neighbours.
+func (g *Graph) CountCliques(k int, keep func(clique []int) bool) int {
+	if k <= 0 {
+		return 0
+	}
+	order, _ := g.DegeneracyOrder()
+	rank := make([]int, g.Len())
+	for i, v := range order {
+		rank[v] = i
+	}
+	forward := make([]bitset, g.Len())
+	for v := range forward {
+		forward[v] = newBitset(g.Len())
+		for _, u := range g.nbrs[v] {
+			if rank[u] > rank[v] {
+				forward[v].set(u)
+			}
+		}
+	}
+
+	count := 0
+	clique := make([]int, 0, k)
+	var grow func(cand bitset)
+	grow = func(cand bitset) {
+		if len(clique) == k {
+			if keep == nil || keep(clique) {
+				count++
+			}
+			return
+		}
+		cand.each(func(v int) {
+			clique = append(clique, v)
+			grow(cand.and(forward[v]))
+			clique = clique[:len(clique)-1]
+		})
+	}
+	for v := range g.Len() {
+		clique = append(clique[:0], v)
+		grow(forward[v].clone())
+	}
+	return count
+}
+
+// Components returns the connected components, each sorted by node id.
+func (g *Graph) Components() [][]int {
+	seen := make([]bool, g.Len())
+	var comps [][]int
+	for start := range g.Len() {
+		if seen[start] {
+			continue
+		}
+		seen[start] = true
+		comp := []int{start}
+		for i := 0; i < len(comp); i++ {
+			for _, u := range g.nbrs[comp[i]] {
+				if !seen[u] {
+					seen[u] = true
+					comp = append(comp, u)
+				}
+			}
+		}
+		sort.Ints(comp)
+		comps = append(comps, comp)
+	}
+	return comps
+}
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"sort"
 	"strconv"
 	"strings"
+
+	"aoc/budget"
 )
 
 type Gate struct {
@@ -117,7 +121,10 @@ func (c *Circuit) findGateByOutput(output string) *Gate {
 	return nil
 }
 
-func (c *Circuit) findSwappedWires() []string {
+// findSwappedWires greedily keeps the first swap that extends the run of
+// correctly wired adder bits. If ctx ends first it returns the swaps found
+// so far.
+func (c *Circuit) findSwappedWires(ctx context.Context) ([]string, error) {
 	var swaps []string
 
 	// Try 4 rounds of swaps (4 pairs = 8 wires)
@@ -136,6 +143,10 @@ func (c *Circuit) findSwappedWires() []string {
 				if x == y {
 					continue
 				}
+				if err := ctx.Err(); err != nil {
+					sort.Strings(swaps)
+					return swaps, err
+				}
 
 				// Try swapping x and y
 				c.swapOutputs(x, y)
@@ -160,7 +171,7 @@ func (c *Circuit) findSwappedWires() []string {
 	}
 
 	sort.Strings(swaps)
-	return swaps
+	return swaps, nil
 }
 
 func (c *Circuit) getAllOutputs() map[string]bool {
@@ -297,11 +308,14 @@ func solvePart1(input string) int64 {
 	return circuit.getZValue()
 }
 
-func solvePart2(input string) string {
+func solvePart2(ctx context.Context, input string) (string, error) {
 	circuit := parseInput(input)
 
 	fmt.Println("=== FINDING SWAPPED WIRES ===")
-	swappedWires := circuit.findSwappedWires()
+	swappedWires, err := circuit.findSwappedWires(ctx)
+	if err != nil {
+		return strings.Join(swappedWires, ","), err
+	}
 
 	fmt.Printf("\nFound %d swapped wires: %v\n", len(swappedWires), swappedWires)
 
@@ -309,10 +323,13 @@ func solvePart2(input string) string {
 		fmt.Printf("Warning: Expected 8 wires, found %d\n", len(swappedWires))
 	}
 
-	return strings.Join(swappedWires, ",")
+	return strings.Join(swappedWires, ","), nil
 }
 
 func main() {
+	timeout := flag.Duration("timeout", 0, "time budget per part, e.g. 2s (0 = unlimited)")
+	flag.Parse()
+
 	content, err := os.ReadFile("input.txt")
 	if err != nil {
 		log.Fatal("Error reading input.txt:", err)
@@ -320,7 +337,10 @@ func main() {
 
 	input := string(content)
 
-	fmt.Printf("Part 1: %d\n", solvePart1(input))
-	fmt.Printf("Part 2: %s\n", solvePart2(input))
+	budget.Run("Part 1", *timeout, func(ctx context.Context) (any, error) {
+		return solvePart1(input), nil
+	})
+	budget.Run("Part 2", *timeout, func(ctx context.Context) (any, error) {
+		return solvePart2(ctx, input)
+	})
 }
-
@@ -2,11 +2,13 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
-	"runtime"
-	"sync"
+
+	"aoc/parallel"
 )
 
 type State struct {
@@ -121,49 +123,34 @@ func causesLoop(grid [][]byte, sx, sy, dir int) bool {
 	}
 }
 
-func countLoopObstacles(lines []string, sx, sy, dir int) int {
+func countLoopObstacles(ctx context.Context, opts parallel.Options, lines []string, sx, sy, dir int) (int, error) {
 	grid := copyGrid(lines)
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-	count := 0
-
-	maxWorkers := runtime.NumCPU()
-	sem := make(chan struct{}, maxWorkers)
-
-	for i, row := range grid {
-		for j := range row {
-			if row[j] != '.' || (i == sx && j == sy) {
-				continue
-			}
-
-			sem <- struct{}{}
-			wg.Add(1)
-
-			go func(i, j int) {
-				defer func() {
-					<-sem
-					wg.Done()
-				}()
-				gridCopy := copyGrid(lines)
-				gridCopy[i][j] = '#'
-
-				if causesLoop(gridCopy, sx, sy, dir) {
-					mu.Lock()
-					count++
-					mu.Unlock()
-				}
-			}(i, j)
+	var candidates []parallel.Cell
+	for _, c := range parallel.Cells(len(grid), len(grid[0])) {
+		if grid[c.Row][c.Col] == '.' && (c.Row != sx || c.Col != sy) {
+			candidates = append(candidates, c)
 		}
 	}
-	wg.Wait()
-	return count
+
+	return parallel.Count(ctx, opts, candidates, func(c parallel.Cell) bool {
+		gridCopy := copyGrid(lines)
+		gridCopy[c.Row][c.Col] = '#'
+		return causesLoop(gridCopy, sx, sy, dir)
+	})
 }
 
 func main() {
+	workers := flag.Int("workers", 0, "parallel workers (0 = one per CPU)")
+	flag.Parse()
+
 	lines := readLines("input.txt")
 	grid := copyGrid(lines)
 	sx, sy, dir := findGuard(grid)
 	// INFO: Part 1
 	fmt.Println("Part 1:", simulateGuardPath(grid, sx, sy, dir))
-	fmt.Println("Part 2:", countLoopObstacles(lines, sx, sy, dir))
+	part2, err := countLoopObstacles(context.Background(), parallel.Options{Workers: *workers}, lines, sx, sy, dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Part 2:", part2)
 }
@@ -2,12 +2,16 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"math"
 	"os"
 	"strconv"
 	"strings"
+
+	"aoc/parallel"
 )
 
 func parseLine(line string) (int, []int) {
@@ -101,19 +105,41 @@ func readLines(path string) []string {
 	return lines
 }
 
+type equation struct {
+	target int
+	nums   []int
+}
+
+// calibrationTotals sums the targets reachable with +,* and with +,*,||.
+func calibrationTotals(ctx context.Context, opts parallel.Options, lines []string) (int, int, error) {
+	equations := make([]equation, len(lines))
+	for i, line := range lines {
+		equations[i].target, equations[i].nums = parseLine(line)
+	}
+
+	totals, err := parallel.Reduce(ctx, opts, equations,
+		func() [2]int { return [2]int{} },
+		func(acc [2]int, eq equation) [2]int {
+			if validCombinationExists(eq.target, eq.nums) {
+				acc[0] += eq.target
+			}
+			if validCombinationExistsPart2(eq.target, eq.nums) {
+				acc[1] += eq.target
+			}
+			return acc
+		},
+		func(a, b [2]int) [2]int { return [2]int{a[0] + b[0], a[1] + b[1]} })
+	return totals[0], totals[1], err
+}
+
 func main() {
-	lines := readLines("input.txt")
-	part1Result := 0
-	part2Result := 0
-	for _, line := range lines {
-		target, nums := parseLine(line)
-		if validCombinationExists(target, nums) {
-			part1Result += target
-		}
+	workers := flag.Int("workers", 0, "parallel workers (0 = one per CPU)")
+	flag.Parse()
 
-		if validCombinationExistsPart2(target, nums) {
-			part2Result += target
-		}
+	lines := readLines("input.txt")
+	part1Result, part2Result, err := calibrationTotals(context.Background(), parallel.Options{Workers: *workers}, lines)
+	if err != nil {
+		log.Fatal(err)
 	}
 	fmt.Println("Total valid combinations:", part1Result)
 	fmt.Println("Total valid combinations for part 2:", part2Result)
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Span is a run of blocks. Files use their integer ID; free spans use -1.
+type Span struct {
+	ID, Start, Len int
+}
+
+const FreeID = -1
+
+// Disk keeps only file spans; free space is whatever lies between them.
+// A file may own several spans once block-wise compaction has split it.
+type Disk struct {
+	Files []Span
+	Size  int
+}
+
+func ParseDisk(input string) (*Disk, error) {
+	d := &Disk{}
+	for i, c := range strings.TrimSpace(input) {
+		if c < '0' || c > '9' {
+			return nil, fmt.Errorf("disk map: unexpected %q at %d", c, i)
+		}
+		size := int(c - '0')
+		if i%2 == 0 && size > 0 {
+			d.Files = append(d.Files, Span{i / 2, d.Size, size})
+		}
+		d.Size += size
+	}
+	return d, nil
+}
+
+func (d *Disk) Clone() *Disk {
+	return &Disk{append([]Span(nil), d.Files...), d.Size}
+}
+
+func (d *Disk) sortByStart() {
+	sort.Slice(d.Files, func(i, j int) bool { return d.Files[i].Start < d.Files[j].Start })
+}
+
+// FreeSpans returns the gaps between files, left to right.
+func (d *Disk) FreeSpans() []Span {
+	d.sortByStart()
+	var free []Span
+	pos := 0
+	for _, f := range d.Files {
+		if f.Start > pos {
+			free = append(free, Span{FreeID, pos, f.Start - pos})
+		}
+		pos = f.Start + f.Len
+	}
+	if pos < d.Size {
+		free = append(free, Span{FreeID, pos, d.Size - pos})
+	}
+	return free
+}
+
+// Checksum sums position*ID over every file block, one span at a time.
+func (d *Disk) Checksum() int64 {
+	var sum int64
+	for _, f := range d.Files {
+		positions := int64(f.Len)*int64(f.Start) + int64(f.Len)*int64(f.Len-1)/2
+		sum += int64(f.ID) * positions
+	}
+	return sum
+}
+
+// Format renders one cell per block. IDs are padded to the widest ID and,
+// once that is more than one digit, separated by spaces, so 10 never reads
+// as 1 followed by 0.
+func (d *Disk) Format() string {
+	maxID := 0
+	for _, f := range d.Files {
+		maxID = max(maxID, f.ID)
+	}
+	width := len(strconv.Itoa(maxID))
+	sep := ""
+	if width > 1 {
+		sep = " "
+	}
+
+	spans := append(d.FreeSpans(), d.Files...)
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+
+	cells := make([]string, 0, d.Size)
+	free := strings.Repeat(".", width)
+	for _, s := range spans {
+		cell := free
+		if s.ID != FreeID {
+			cell = fmt.Sprintf("%*d", width, s.ID)
+		}
+		for range s.Len {
+			cells = append(cells, cell)
+		}
+	}
+	return strings.Join(cells, sep)
+}
+
+// Strategy rearranges a disk in place.
+type Strategy func(d *Disk)
+
+var strategies = []struct {
+	name    string
+	compact Strategy
+}{
+	{"block-wise", CompactBlocks},
+	{"first-fit", CompactFirstFit},
+	{"best-fit", CompactBestFit},
+}
+
+// CompactBlocks moves single blocks from the end of the disk into the
+// leftmost free space, splitting files as needed.
+func CompactBlocks(d *Disk) {
+	free := d.FreeSpans()
+	files := d.Files
+	var moved []Span
+
+	fi, j := 0, len(files)-1
+	for fi < len(free) && j >= 0 {
+		fr, f := &free[fi], &files[j]
+		if f.Len == 0 {
+			j--
+			continue
+		}
+		if fr.Len == 0 {
+			fi++
+			continue
+		}
+		if fr.Start >= f.Start {
+			break
+		}
+		n := min(fr.Len, f.Len)
+		moved = append(moved, Span{f.ID, fr.Start, n})
+		fr.Start += n
+		fr.Len -= n
+		f.Len -= n
+	}
+
+	var out []Span
+	for _, s := range append(files, moved...) {
+		if s.Len > 0 {
+			out = append(out, s)
+		}
+	}
+	d.Files = out
+	d.sortByStart()
+	d.merge()
+}
+
+// merge joins adjacent spans of the same file.
+func (d *Disk) merge() {
+	var out []Span
+	for _, s := range d.Files {
+		if n := len(out); n > 0 && out[n-1].ID == s.ID && out[n-1].Start+out[n-1].Len == s.Start {
+			out[n-1].Len += s.Len
+			continue
+		}
+		out = append(out, s)
+	}
+	d.Files = out
+}
+
+// CompactFirstFit moves each whole file, highest ID first, into the
+// leftmost free span that fits it.
+func CompactFirstFit(d *Disk) { compactWholeFiles(d, false) }
+
+// CompactBestFit moves each whole file, highest ID first, into the
+// smallest free span to its left that fits it, leftmost on ties.
+func CompactBestFit(d *Disk) { compactWholeFiles(d, true) }
+
+type startHeap []int
+
+func (h startHeap) Len() int           { return len(h) }
+func (h startHeap) Less(i, j int) bool { return h[i] < h[j] }
+func (h startHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *startHeap) Push(x any)        { *h = append(*h, x.(int)) }
+func (h *startHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// compactWholeFiles keeps one min-heap of start positions per free span
+// size, so finding a slot costs O(maxLen·log n) instead of a disk rescan.
+// Space a file leaves behind is never reused: only lower IDs move later,
+// and they only move left of where they already are.
+func compactWholeFiles(d *Disk, bestFit bool) {
+	free := d.FreeSpans()
+	maxLen := 0
+	for _, f := range free {
+		maxLen = max(maxLen, f.Len)
+	}
+	bySize := make([]startHeap, maxLen+1)
+	for _, f := range free {
+		bySize[f.Len] = append(bySize[f.Len], f.Start)
+	}
+	for i := range bySize {
+		heap.Init(&bySize[i])
+	}
+
+	order := make([]int, len(d.Files))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		fa, fb := d.Files[order[a]], d.Files[order[b]]
+		if fa.ID != fb.ID {
+			return fa.ID > fb.ID
+		}
+		return fa.Start > fb.Start
+	})
+
+	for _, idx := range order {
+		f := &d.Files[idx]
+		chosen := -1
+		for size := f.Len; size <= maxLen; size++ {
+			h := bySize[size]
+			if len(h) == 0 || h[0] >= f.Start {
+				continue
+			}
+			if chosen == -1 || h[0] < bySize[chosen][0] {
+				chosen = size
+				if bestFit {
+					break
+				}
+			}
+		}
+		if chosen == -1 {
+			continue
+		}
+		start := heap.Pop(&bySize[chosen]).(int)
+		f.Start = start
+		if rest := chosen - f.Len; rest > 0 {
+			heap.Push(&bySize[rest], start+f.Len)
+		}
+	}
+	d.sortByStart()
+	d.merge()
+}
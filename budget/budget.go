@@ -0,0 +1,75 @@
+// Package budget runs puzzle parts under a time limit.
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Grace is how long Run waits after the deadline for a solver to notice
+// and hand back its best answer so far.
+const Grace = 100 * time.Millisecond
+
+var out io.Writer = os.Stdout
+
+type outcome struct {
+	result any
+	err    error
+}
+
+// Run runs solve under a deadline of timeout (none when zero) and prints
+// its answer. A solver that is cancelled should return the best answer it
+// has so far along with ctx.Err(); that answer is reported as partial
+// unless it is nil or prints as nothing. solve runs in its own goroutine,
+// so one that ignores ctx is still reported as timed out: it is left to
+// finish in the background and whatever it returns is dropped.
+//
+// Run returns what solve returned, or nil and ctx.Err() for a solver it
+// gave up on. Callers should take results from here rather than from
+// variables the solver writes, which an abandoned solver may still touch.
+func Run(name string, timeout time.Duration, solve func(ctx context.Context) (any, error)) (any, error) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := solve(ctx)
+		done <- outcome{result, err}
+	}()
+
+	var o outcome
+	select {
+	case o = <-done:
+	case <-ctx.Done():
+		select {
+		case o = <-done:
+		case <-time.After(Grace):
+			o = outcome{nil, ctx.Err()}
+		}
+	}
+
+	switch {
+	case errors.Is(o.err, context.DeadlineExceeded):
+		fmt.Fprintf(out, "%s: timed out after %s", name, time.Since(start).Round(time.Millisecond))
+		if o.result != nil {
+			if partial := fmt.Sprint(o.result); partial != "" {
+				fmt.Fprintf(out, " (best so far: %s)", partial)
+			}
+		}
+		fmt.Fprintln(out)
+	case o.err != nil:
+		fmt.Fprintf(out, "%s: %v\n", name, o.err)
+	default:
+		fmt.Fprintf(out, "%s: %v\n", name, o.result)
+	}
+	return o.result, o.err
+}
@@ -0,0 +1,102 @@
+package budget
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func capture(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	old := out
+	out = &buf
+	t.Cleanup(func() { out = old })
+	return &buf
+}
+
+func TestFinishes(t *testing.T) {
+	buf := capture(t)
+	v, err := Run("Part 1", time.Second, func(ctx context.Context) (any, error) { return 42, nil })
+	if v != 42 || err != nil {
+		t.Errorf("Run = %v, %v, want 42, nil", v, err)
+	}
+	if got := buf.String(); got != "Part 1: 42\n" {
+		t.Errorf("printed %q", got)
+	}
+}
+
+func TestNoTimeout(t *testing.T) {
+	buf := capture(t)
+	Run("Part 1", 0, func(ctx context.Context) (any, error) {
+		if _, ok := ctx.Deadline(); ok {
+			t.Error("zero timeout set a deadline")
+		}
+		return "ok", nil
+	})
+	if got := buf.String(); got != "Part 1: ok\n" {
+		t.Errorf("printed %q", got)
+	}
+}
+
+func TestSolverError(t *testing.T) {
+	buf := capture(t)
+	_, err := Run("Part 2", time.Second, func(ctx context.Context) (any, error) { return nil, errors.New("bad input") })
+	if err == nil || buf.String() != "Part 2: bad input\n" {
+		t.Errorf("Run error %v, printed %q", err, buf.String())
+	}
+}
+
+func TestIgnoresDeadline(t *testing.T) {
+	buf := capture(t)
+	release := make(chan struct{})
+	defer close(release)
+	start := time.Now()
+	v, err := Run("Part 1", 20*time.Millisecond, func(ctx context.Context) (any, error) {
+		<-release // never looks at ctx
+		return 1, nil
+	})
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond+Grace+time.Second {
+		t.Errorf("Run took %s, well past the budget", elapsed)
+	}
+	if v != nil || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run = %v, %v, want nil and a deadline error", v, err)
+	}
+	if got := buf.String(); !strings.HasPrefix(got, "Part 1: timed out after ") || strings.Contains(got, "best so far") {
+		t.Errorf("printed %q", got)
+	}
+}
+
+func TestPartial(t *testing.T) {
+	buf := capture(t)
+	v, err := Run("Part 2", 10*time.Millisecond, func(ctx context.Context) (any, error) {
+		<-ctx.Done()
+		return 17, ctx.Err()
+	})
+	if v != 17 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run = %v, %v, want the partial 17", v, err)
+	}
+	if got := buf.String(); !strings.HasSuffix(got, " (best so far: 17)\n") {
+		t.Errorf("printed %q", got)
+	}
+}
+
+type empty struct{}
+
+func (empty) String() string { return "" }
+
+func TestNoPartial(t *testing.T) {
+	for _, partial := range []any{nil, empty{}} {
+		buf := capture(t)
+		Run("Part 2", 10*time.Millisecond, func(ctx context.Context) (any, error) {
+			<-ctx.Done()
+			return partial, ctx.Err()
+		})
+		if got := buf.String(); strings.Contains(got, "best so far") {
+			t.Errorf("partial %#v printed %q", partial, got)
+		}
+	}
+}
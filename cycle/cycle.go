@@ -0,0 +1,113 @@
+// Package cycle finds where an eventually periodic sequence starts to
+// repeat, so simulations can skip ahead instead of running every step.
+package cycle
+
+import "math"
+
+// Cycle describes an eventually periodic sequence x0, f(x0), f(f(x0)), ...:
+// the state at index Start is the first to recur, every Length steps.
+type Cycle struct {
+	Start, Length int
+}
+
+// At maps any index of the sequence onto the equivalent index below
+// Start+Length.
+func (c Cycle) At(n int) int {
+	if n < c.Start+c.Length {
+		return n
+	}
+	return c.Start + (n-c.Start)%c.Length
+}
+
+// Floyd finds the cycle with the tortoise-and-hare method in O(1) memory.
+// States are compared through key, so step must not mutate its argument.
+func Floyd[S any, K comparable](x0 S, step func(S) S, key func(S) K) Cycle {
+	tortoise, hare := step(x0), step(step(x0))
+	for key(tortoise) != key(hare) {
+		tortoise, hare = step(tortoise), step(step(hare))
+	}
+
+	start := 0
+	tortoise = x0
+	for key(tortoise) != key(hare) {
+		tortoise, hare = step(tortoise), step(hare)
+		start++
+	}
+
+	length := 1
+	hare = step(tortoise)
+	for key(tortoise) != key(hare) {
+		hare = step(hare)
+		length++
+	}
+	return Cycle{start, length}
+}
+
+// Brent finds the cycle with Brent's power-of-two search, which needs
+// fewer step calls than Floyd and also runs in O(1) memory.
+func Brent[S any, K comparable](x0 S, step func(S) S, key func(S) K) Cycle {
+	power, length := 1, 1
+	tortoise, hare := x0, step(x0)
+	for key(tortoise) != key(hare) {
+		if power == length {
+			tortoise = hare
+			power *= 2
+			length = 0
+		}
+		hare = step(hare)
+		length++
+	}
+
+	tortoise, hare = x0, x0
+	for range length {
+		hare = step(hare)
+	}
+	start := 0
+	for key(tortoise) != key(hare) {
+		tortoise, hare = step(tortoise), step(hare)
+		start++
+	}
+	return Cycle{start, length}
+}
+
+// Detect steps until a state's key repeats, remembering every state so
+// far. It costs memory per state but calls step only Start+Length times,
+// and the returned history lets callers read any state without replaying.
+func Detect[S any, K comparable](x0 S, step func(S) S, key func(S) K) (Cycle, []S) {
+	c, history, _ := DetectWithin(x0, step, key, math.MaxInt)
+	return c, history
+}
+
+// DetectWithin is Detect for sequences that may take too long to repeat:
+// it calls step at most limit times and reports false if no key repeated.
+func DetectWithin[S any, K comparable](x0 S, step func(S) S, key func(S) K, limit int) (Cycle, []S, bool) {
+	seen := map[K]int{key(x0): 0}
+	history := []S{x0}
+	for x := x0; len(history) <= limit; {
+		x = step(x)
+		k := key(x)
+		if first, ok := seen[k]; ok {
+			return Cycle{first, len(history) - first}, history, true
+		}
+		seen[k] = len(history)
+		history = append(history, x)
+	}
+	return Cycle{}, history, false
+}
+
+// FastForward returns the state after n steps. It stops early if n comes
+// before any repeat, and otherwise jumps over the whole cycles.
+func FastForward[S any, K comparable](x0 S, step func(S) S, key func(S) K, n int) S {
+	seen := map[K]int{key(x0): 0}
+	history := []S{x0}
+	for x := x0; len(history) <= n; {
+		x = step(x)
+		k := key(x)
+		if first, ok := seen[k]; ok {
+			return history[Cycle{first, len(history) - first}.At(n)]
+		}
+		seen[k] = len(history)
+		history = append(history, x)
+	}
+	return history[n]
+}
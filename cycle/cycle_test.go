@@ -0,0 +1,131 @@
+package cycle
+
+import "testing"
+
+// table builds a sequence from a successor table: state i steps to next[i].
+func table(next ...int) func(int) int {
+	return func(i int) int { return next[i] }
+}
+
+func identity(i int) int { return i }
+
+var sequences = []struct {
+	name string
+	step func(int) int
+	want Cycle
+}{
+	// 0 1 2 3 4 2 3 4 ...
+	{"tail", table(1, 2, 3, 4, 2), Cycle{2, 3}},
+	// 0 1 2 0 1 2 ...
+	{"pure", table(1, 2, 0), Cycle{0, 3}},
+	// 0 1 1 1 ...
+	{"fixed point", table(1, 1), Cycle{1, 1}},
+	// 0 0 0 ...
+	{"constant", table(0), Cycle{0, 1}},
+	// 0 1 ... 9 then 10..16 looping: a long tail and a long cycle.
+	{"long", func(i int) int {
+		if i == 16 {
+			return 10
+		}
+		return i + 1
+	}, Cycle{10, 7}},
+}
+
+func TestDetectors(t *testing.T) {
+	detectors := map[string]func(int, func(int) int, func(int) int) Cycle{
+		"Floyd": Floyd[int, int],
+		"Brent": Brent[int, int],
+		"Detect": func(x0 int, step func(int) int, key func(int) int) Cycle {
+			c, _ := Detect(x0, step, key)
+			return c
+		},
+	}
+	for _, seq := range sequences {
+		for name, detect := range detectors {
+			if got := detect(0, seq.step, identity); got != seq.want {
+				t.Errorf("%s on %s: got %+v, want %+v", name, seq.name, got, seq.want)
+			}
+		}
+	}
+}
+
+func TestDetectHistory(t *testing.T) {
+	for _, seq := range sequences {
+		c, history := Detect(0, seq.step, identity)
+		if len(history) != c.Start+c.Length {
+			t.Errorf("%s: history has %d states, want %d", seq.name, len(history), c.Start+c.Length)
+		}
+		x := 0
+		for i, h := range history {
+			if h != x {
+				t.Errorf("%s: history[%d] = %d, want %d", seq.name, i, h, x)
+				break
+			}
+			x = seq.step(x)
+		}
+	}
+}
+
+func TestDetectWithin(t *testing.T) {
+	// The "long" sequence first repeats on step 17.
+	step := sequences[len(sequences)-1].step
+	if c, history, ok := DetectWithin(0, step, identity, 17); !ok || c != (Cycle{10, 7}) || len(history) != 17 {
+		t.Errorf("DetectWithin(17) = %+v, %d states, %v", c, len(history), ok)
+	}
+	calls := 0
+	counted := func(i int) int { calls++; return step(i) }
+	if _, history, ok := DetectWithin(0, counted, identity, 16); ok || len(history) != 17 || calls != 16 {
+		t.Errorf("DetectWithin(16) = %d states, %v after %d steps, want no cycle after 16", len(history), ok, calls)
+	}
+}
+
+func TestAt(t *testing.T) {
+	c := Cycle{2, 3}
+	for n, want := range map[int]int{0: 0, 1: 1, 2: 2, 4: 4, 5: 2, 6: 3, 1000: 4} {
+		if got := c.At(n); got != want {
+			t.Errorf("At(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+func TestFastForward(t *testing.T) {
+	for _, seq := range sequences {
+		start := seq.want.Start
+		// Before, at and after the cycle start, and far beyond it.
+		for _, n := range []int{0, max(start-1, 0), start, start + 1, start + seq.want.Length, 12345} {
+			want := 0
+			for range n {
+				want = seq.step(want)
+			}
+			if got := FastForward(0, seq.step, identity, n); got != want {
+				t.Errorf("%s: FastForward(%d) = %d, want %d", seq.name, n, got, want)
+			}
+		}
+	}
+}
+
+func TestFastForwardStopsEarly(t *testing.T) {
+	// A sequence that never repeats must still work for small n.
+	calls := 0
+	step := func(i int) int { calls++; return i + 1 }
+	if got := FastForward(0, step, identity, 5); got != 5 {
+		t.Errorf("FastForward(5) = %d, want 5", got)
+	}
+	if calls != 5 {
+		t.Errorf("step called %d times, want 5", calls)
+	}
+}
+
+func TestKeyedStates(t *testing.T) {
+	// States compare through key, so distinct values with equal keys count
+	// as a repeat.
+	step := func(s [2]int) [2]int { return [2]int{s[0] + 1, (s[1] + 1) % 4} }
+	key := func(s [2]int) int { return s[1] }
+	want := Cycle{0, 4}
+	if got := Brent([2]int{0, 0}, step, key); got != want {
+		t.Errorf("Brent = %+v, want %+v", got, want)
+	}
+	if got := Floyd([2]int{0, 0}, step, key); got != want {
+		t.Errorf("Floyd = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,523 @@
+// Package linalg does exact linear algebra over the rationals and over the
+// integers, for puzzles whose answers must not suffer float rounding.
+package linalg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+var (
+	ErrSingular          = errors.New("linalg: singular system")
+	ErrInconsistent      = errors.New("linalg: inconsistent system")
+	ErrOverflow          = errors.New("linalg: int64 overflow")
+	ErrNoIntegerSolution = errors.New("linalg: no integer solution")
+)
+
+// RatMatrix is a dense matrix of exact rationals, indexed [row][col].
+type RatMatrix [][]*big.Rat
+
+func NewRatMatrix(rows, cols int) RatMatrix {
+	m := make(RatMatrix, rows)
+	for i := range m {
+		m[i] = make([]*big.Rat, cols)
+		for j := range m[i] {
+			m[i][j] = new(big.Rat)
+		}
+	}
+	return m
+}
+
+func (m RatMatrix) Clone() RatMatrix {
+	c := make(RatMatrix, len(m))
+	for i, row := range m {
+		c[i] = make([]*big.Rat, len(row))
+		for j, v := range row {
+			c[i][j] = new(big.Rat).Set(v)
+		}
+	}
+	return c
+}
+
+func (m RatMatrix) cols() int {
+	if len(m) == 0 {
+		return 0
+	}
+	return len(m[0])
+}
+
+// RowReduce returns the reduced row echelon form of m, the pivot column of
+// each non-zero row, and the determinant when m is square (zero otherwise).
+func (m RatMatrix) RowReduce() (RatMatrix, []int, *big.Rat) {
+	r := m.Clone()
+	det := big.NewRat(1, 1)
+	var pivots []int
+	row := 0
+	for col := 0; col < r.cols() && row < len(r); col++ {
+		pivot := -1
+		for i := row; i < len(r); i++ {
+			if r[i][col].Sign() != 0 {
+				pivot = i
+				break
+			}
+		}
+		if pivot < 0 {
+			continue
+		}
+		if pivot != row {
+			r[row], r[pivot] = r[pivot], r[row]
+			det.Neg(det)
+		}
+		p := new(big.Rat).Set(r[row][col])
+		det.Mul(det, p)
+		for j := col; j < r.cols(); j++ {
+			r[row][j].Quo(r[row][j], p)
+		}
+		for i := range r {
+			if i == row || r[i][col].Sign() == 0 {
+				continue
+			}
+			f := new(big.Rat).Set(r[i][col])
+			for j := col; j < r.cols(); j++ {
+				r[i][j].Sub(r[i][j], new(big.Rat).Mul(f, r[row][j]))
+			}
+		}
+		pivots = append(pivots, col)
+		row++
+	}
+	if len(r) != r.cols() || len(pivots) < len(r) {
+		det.SetInt64(0)
+	}
+	return r, pivots, det
+}
+
+func (m RatMatrix) Det() *big.Rat {
+	_, _, det := m.RowReduce()
+	return det
+}
+
+func (m RatMatrix) Rank() int {
+	_, pivots, _ := m.RowReduce()
+	return len(pivots)
+}
+
+// NullSpace returns a basis of the vectors x with m·x = 0.
+func (m RatMatrix) NullSpace() [][]*big.Rat {
+	r, pivots, _ := m.RowReduce()
+	isPivot := make(map[int]int, len(pivots))
+	for i, c := range pivots {
+		isPivot[c] = i
+	}
+	var basis [][]*big.Rat
+	for free := range m.cols() {
+		if _, ok := isPivot[free]; ok {
+			continue
+		}
+		v := make([]*big.Rat, m.cols())
+		for j := range v {
+			v[j] = new(big.Rat)
+		}
+		v[free].SetInt64(1)
+		for i, c := range pivots {
+			v[c].Neg(r[i][free])
+		}
+		basis = append(basis, v)
+	}
+	return basis
+}
+
+// SolveRat returns the unique x with A·x = b. It fails with
+// ErrInconsistent when there is no solution and ErrSingular when there are
+// infinitely many.
+func SolveRat(A RatMatrix, b []*big.Rat) ([]*big.Rat, error) {
+	n := A.cols()
+	aug := NewRatMatrix(len(A), n+1)
+	for i := range A {
+		for j := range n {
+			aug[i][j].Set(A[i][j])
+		}
+		aug[i][n].Set(b[i])
+	}
+	r, pivots, _ := aug.RowReduce()
+	if len(pivots) > 0 && pivots[len(pivots)-1] == n {
+		return nil, ErrInconsistent
+	}
+	if len(pivots) < n {
+		return nil, ErrSingular
+	}
+	x := make([]*big.Rat, n)
+	for i := range n {
+		x[i] = new(big.Rat).Set(r[i][n])
+	}
+	return x, nil
+}
+
+// checked does int64 arithmetic, remembering the first overflow so a run
+// of operations needs only one error check at the end.
+type checked struct{ err error }
+
+func (c *checked) add(a, b int64) int64 {
+	s := a + b
+	if (s > a) != (b > 0) && c.err == nil {
+		c.err = fmt.Errorf("%w: %d + %d", ErrOverflow, a, b)
+	}
+	return s
+}
+
+func (c *checked) sub(a, b int64) int64 {
+	d := a - b
+	if (d < a) != (b > 0) && c.err == nil {
+		c.err = fmt.Errorf("%w: %d - %d", ErrOverflow, a, b)
+	}
+	return d
+}
+
+func (c *checked) mul(a, b int64) int64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	p := a * b
+	if (p/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64)) && c.err == nil {
+		c.err = fmt.Errorf("%w: %d * %d", ErrOverflow, a, b)
+	}
+	return p
+}
+
+// IntMatrix is a dense int64 matrix whose operations fail with ErrOverflow
+// rather than wrap.
+type IntMatrix [][]int64
+
+func NewIntMatrix(rows, cols int) IntMatrix {
+	m := make(IntMatrix, rows)
+	for i := range m {
+		m[i] = make([]int64, cols)
+	}
+	return m
+}
+
+func Identity(n int) IntMatrix {
+	m := NewIntMatrix(n, n)
+	for i := range n {
+		m[i][i] = 1
+	}
+	return m
+}
+
+func (m IntMatrix) Clone() IntMatrix {
+	c := make(IntMatrix, len(m))
+	for i, row := range m {
+		c[i] = append([]int64(nil), row...)
+	}
+	return c
+}
+
+func (m IntMatrix) cols() int {
+	if len(m) == 0 {
+		return 0
+	}
+	return len(m[0])
+}
+
+func (m IntMatrix) Rat() RatMatrix {
+	r := NewRatMatrix(len(m), m.cols())
+	for i, row := range m {
+		for j, v := range row {
+			r[i][j].SetInt64(v)
+		}
+	}
+	return r
+}
+
+func (m IntMatrix) Mul(n IntMatrix) (IntMatrix, error) {
+	var c checked
+	out := NewIntMatrix(len(m), n.cols())
+	for i := range m {
+		for j := range n.cols() {
+			for k := range n {
+				out[i][j] = c.add(out[i][j], c.mul(m[i][k], n[k][j]))
+			}
+		}
+	}
+	return out, c.err
+}
+
+func (m IntMatrix) Apply(x []int64) ([]int64, error) {
+	var c checked
+	out := make([]int64, len(m))
+	for i, row := range m {
+		for j, v := range row {
+			out[i] = c.add(out[i], c.mul(v, x[j]))
+		}
+	}
+	return out, c.err
+}
+
+// Det is the determinant by Bareiss' fraction-free elimination, which keeps
+// every intermediate value a minor of m and so no larger than it must be.
+func (m IntMatrix) Det() (int64, error) {
+	n := len(m)
+	if n != m.cols() {
+		return 0, fmt.Errorf("linalg: determinant of %dx%d matrix", n, m.cols())
+	}
+	a := m.Clone()
+	var c checked
+	sign, prev := int64(1), int64(1)
+	for k := range n - 1 {
+		if a[k][k] == 0 {
+			swap := -1
+			for i := k + 1; i < n; i++ {
+				if a[i][k] != 0 {
+					swap = i
+					break
+				}
+			}
+			if swap < 0 {
+				return 0, c.err
+			}
+			a[k], a[swap] = a[swap], a[k]
+			sign = -sign
+		}
+		for i := k + 1; i < n; i++ {
+			for j := k + 1; j < n; j++ {
+				a[i][j] = c.sub(c.mul(a[i][j], a[k][k]), c.mul(a[i][k], a[k][j])) / prev
+			}
+		}
+		prev = a[k][k]
+	}
+	if n == 0 {
+		return 1, nil
+	}
+	return c.mul(sign, a[n-1][n-1]), c.err
+}
+
+func (m IntMatrix) Rank() int { return m.Rat().Rank() }
+
+// unimodular row and column operations, mirrored onto a transform matrix.
+
+func (m IntMatrix) swapRows(i, j int) { m[i], m[j] = m[j], m[i] }
+
+func (m IntMatrix) negRow(i int) {
+	for j := range m[i] {
+		m[i][j] = -m[i][j]
+	}
+}
+
+// addRow does row[dst] += k·row[src].
+func (m IntMatrix) addRow(c *checked, dst, src int, k int64) {
+	for j := range m[dst] {
+		m[dst][j] = c.add(m[dst][j], c.mul(k, m[src][j]))
+	}
+}
+
+func (m IntMatrix) swapCols(i, j int) {
+	for r := range m {
+		m[r][i], m[r][j] = m[r][j], m[r][i]
+	}
+}
+
+// addCol does col[dst] += k·col[src].
+func (m IntMatrix) addCol(c *checked, dst, src int, k int64) {
+	for r := range m {
+		m[r][dst] = c.add(m[r][dst], c.mul(k, m[r][src]))
+	}
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+func abs64(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// HermiteNormalForm returns H and a unimodular U with U·m = H, where H is
+// in row echelon form, every pivot is positive, and the entries above a
+// pivot lie in [0, pivot).
+func HermiteNormalForm(m IntMatrix) (H, U IntMatrix, err error) {
+	H, U = m.Clone(), Identity(len(m))
+	var c checked
+	row := 0
+	for col := 0; col < H.cols() && row < len(H); col++ {
+		// Euclid down the column: keep moving the smallest non-zero entry
+		// to the pivot row and reducing the rest by it.
+		for {
+			best := -1
+			for i := row; i < len(H); i++ {
+				if H[i][col] != 0 && (best < 0 || abs64(H[i][col]) < abs64(H[best][col])) {
+					best = i
+				}
+			}
+			if best < 0 {
+				break
+			}
+			H.swapRows(row, best)
+			U.swapRows(row, best)
+			done := true
+			for i := row + 1; i < len(H); i++ {
+				if q := H[i][col] / H[row][col]; q != 0 {
+					H.addRow(&c, i, row, -q)
+					U.addRow(&c, i, row, -q)
+				}
+				if H[i][col] != 0 {
+					done = false
+				}
+			}
+			if c.err != nil {
+				return nil, nil, c.err
+			}
+			if done {
+				break
+			}
+		}
+		if H[row][col] == 0 {
+			continue
+		}
+		if H[row][col] < 0 {
+			H.negRow(row)
+			U.negRow(row)
+		}
+		for i := range row {
+			if q := floorDiv(H[i][col], H[row][col]); q != 0 {
+				H.addRow(&c, i, row, -q)
+				U.addRow(&c, i, row, -q)
+			}
+		}
+		row++
+	}
+	return H, U, c.err
+}
+
+// SmithNormalForm returns D and unimodular U, V with U·m·V = D, where D is
+// diagonal with non-negative entries each dividing the next.
+func SmithNormalForm(m IntMatrix) (D, U, V IntMatrix, err error) {
+	D, U, V = m.Clone(), Identity(len(m)), Identity(m.cols())
+	var c checked
+	for t := 0; t < len(D) && t < D.cols(); t++ {
+		for {
+			// Move the smallest non-zero entry of the trailing block to (t,t).
+			bi, bj := -1, -1
+			for i := t; i < len(D); i++ {
+				for j := t; j < D.cols(); j++ {
+					if D[i][j] != 0 && (bi < 0 || abs64(D[i][j]) < abs64(D[bi][bj])) {
+						bi, bj = i, j
+					}
+				}
+			}
+			if bi < 0 {
+				return D, U, V, c.err
+			}
+			D.swapRows(t, bi)
+			U.swapRows(t, bi)
+			D.swapCols(t, bj)
+			V.swapCols(t, bj)
+
+			clean := true
+			for i := t + 1; i < len(D); i++ {
+				if q := D[i][t] / D[t][t]; q != 0 {
+					D.addRow(&c, i, t, -q)
+					U.addRow(&c, i, t, -q)
+				}
+				clean = clean && D[i][t] == 0
+			}
+			for j := t + 1; j < D.cols(); j++ {
+				if q := D[t][j] / D[t][t]; q != 0 {
+					D.addCol(&c, j, t, -q)
+					V.addCol(&c, j, t, -q)
+				}
+				clean = clean && D[t][j] == 0
+			}
+			if c.err != nil {
+				return nil, nil, nil, c.err
+			}
+			if !clean {
+				continue
+			}
+			// The pivot must divide everything left; if not, fold the
+			// offending row in and go round again.
+			offender := -1
+			for i := t + 1; i < len(D) && offender < 0; i++ {
+				for j := t + 1; j < D.cols(); j++ {
+					if D[i][j]%D[t][t] != 0 {
+						offender = i
+						break
+					}
+				}
+			}
+			if offender < 0 {
+				break
+			}
+			D.addRow(&c, t, offender, 1)
+			U.addRow(&c, t, offender, 1)
+		}
+		if D[t][t] < 0 {
+			D.negRow(t)
+			U.negRow(t)
+		}
+	}
+	return D, U, V, c.err
+}
+
+// SolveInt finds an integer x with A·x = b, or ErrNoIntegerSolution. When
+// the solution is unique it is found over the rationals and checked for
+// integrality, which avoids the large transforms Smith form can need.
+// Otherwise the one returned has every free Smith coordinate set to zero;
+// add integer combinations of the null space to reach the others.
+func SolveInt(A IntMatrix, b []int64) ([]int64, error) {
+	rb := make([]*big.Rat, len(b))
+	for i, v := range b {
+		rb[i] = new(big.Rat).SetInt64(v)
+	}
+	x, err := SolveRat(A.Rat(), rb)
+	switch {
+	case errors.Is(err, ErrInconsistent):
+		return nil, err
+	case err == nil:
+		out := make([]int64, len(x))
+		for i, v := range x {
+			if !v.IsInt() {
+				return nil, fmt.Errorf("%w: x[%d] = %s", ErrNoIntegerSolution, i, v.RatString())
+			}
+			if !v.Num().IsInt64() {
+				return nil, fmt.Errorf("%w: x[%d] = %s", ErrOverflow, i, v.RatString())
+			}
+			out[i] = v.Num().Int64()
+		}
+		return out, nil
+	}
+
+	D, U, V, err := SmithNormalForm(A)
+	if err != nil {
+		return nil, err
+	}
+	ub, err := U.Apply(b)
+	if err != nil {
+		return nil, err
+	}
+	// D·y = U·b, then x = V·y.
+	y := make([]int64, A.cols())
+	for i, v := range ub {
+		var d int64
+		if i < len(y) {
+			d = D[i][i]
+		}
+		switch {
+		case d == 0 && v != 0:
+			return nil, ErrNoIntegerSolution
+		case d == 0:
+		case v%d != 0:
+			return nil, fmt.Errorf("%w: %d is not a multiple of %d", ErrNoIntegerSolution, v, d)
+		default:
+			y[i] = v / d
+		}
+	}
+	return V.Apply(y)
+}
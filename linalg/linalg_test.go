@@ -0,0 +1,284 @@
+package linalg
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func randomIntMatrix(rng *rand.Rand, rows, cols int, span int64) IntMatrix {
+	m := NewIntMatrix(rows, cols)
+	for i := range m {
+		for j := range m[i] {
+			m[i][j] = rng.Int63n(2*span+1) - span
+		}
+	}
+	return m
+}
+
+func ratVector(vs ...int64) []*big.Rat {
+	out := make([]*big.Rat, len(vs))
+	for i, v := range vs {
+		out[i] = big.NewRat(v, 1)
+	}
+	return out
+}
+
+func equal(a, b IntMatrix) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for j := range a[i] {
+			if a[i][j] != b[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func mustMul(t *testing.T, a, b IntMatrix) IntMatrix {
+	t.Helper()
+	p, err := a.Mul(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func assertUnimodular(t *testing.T, name string, m IntMatrix) {
+	t.Helper()
+	if d := m.Rat().Det(); d.Cmp(big.NewRat(1, 1)) != 0 && d.Cmp(big.NewRat(-1, 1)) != 0 {
+		t.Errorf("%s has determinant %s, want ±1", name, d.RatString())
+	}
+}
+
+func TestDetMatchesRational(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for range 200 {
+		n := 1 + rng.Intn(5)
+		m := randomIntMatrix(rng, n, n, 9)
+		if rng.Intn(4) == 0 && n > 1 {
+			// Force a singular matrix by repeating a row.
+			copy(m[n-1], m[0])
+		}
+		got, err := m.Det()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := m.Rat().Det()
+		if want.Cmp(big.NewRat(got, 1)) != 0 {
+			t.Fatalf("Det(%v) = %d, want %s", m, got, want.RatString())
+		}
+	}
+}
+
+func TestDetNotSquare(t *testing.T) {
+	if _, err := NewIntMatrix(2, 3).Det(); err == nil {
+		t.Error("expected an error for a 2x3 determinant")
+	}
+}
+
+func TestSmithNormalForm(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for range 200 {
+		rows, cols := 1+rng.Intn(4), 1+rng.Intn(4)
+		A := randomIntMatrix(rng, rows, cols, 6)
+		D, U, V, err := SmithNormalForm(A)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := mustMul(t, mustMul(t, U, A), V); !equal(got, D) {
+			t.Fatalf("U·A·V = %v, want D = %v for A = %v", got, D, A)
+		}
+		assertUnimodular(t, "U", U)
+		assertUnimodular(t, "V", V)
+		var diag []int64
+		for i := range D {
+			for j := range D[i] {
+				switch {
+				case i == j:
+					diag = append(diag, D[i][j])
+				case D[i][j] != 0:
+					t.Fatalf("D = %v is not diagonal", D)
+				}
+			}
+		}
+		for i, d := range diag {
+			if d < 0 {
+				t.Fatalf("D = %v has a negative entry", D)
+			}
+			if i > 0 && diag[i-1] == 0 && d != 0 {
+				t.Fatalf("D = %v has a zero before a non-zero entry", D)
+			}
+			if i > 0 && diag[i-1] != 0 && d%diag[i-1] != 0 {
+				t.Fatalf("D = %v: %d does not divide %d", D, diag[i-1], d)
+			}
+		}
+	}
+}
+
+func TestHermiteNormalForm(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for range 200 {
+		rows, cols := 1+rng.Intn(4), 1+rng.Intn(4)
+		A := randomIntMatrix(rng, rows, cols, 6)
+		H, U, err := HermiteNormalForm(A)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := mustMul(t, U, A); !equal(got, H) {
+			t.Fatalf("U·A = %v, want H = %v for A = %v", got, H, A)
+		}
+		assertUnimodular(t, "U", U)
+		lead := -1
+		for i, row := range H {
+			col := -1
+			for j, v := range row {
+				if v != 0 {
+					col = j
+					break
+				}
+			}
+			if col < 0 {
+				lead = len(row)
+				continue
+			}
+			if col <= lead {
+				t.Fatalf("H = %v is not in row echelon form", H)
+			}
+			lead = col
+			if row[col] <= 0 {
+				t.Fatalf("H = %v has a non-positive pivot", H)
+			}
+			for k := range i {
+				if v := H[k][col]; v < 0 || v >= row[col] {
+					t.Fatalf("H = %v: entry %d above pivot %d is not reduced", H, v, row[col])
+				}
+			}
+		}
+		if got := H.Rank(); got != A.Rank() {
+			t.Fatalf("rank of H is %d, rank of A is %d", got, A.Rank())
+		}
+	}
+}
+
+func TestNullSpace(t *testing.T) {
+	A := IntMatrix{{1, 2, 3, 4}, {2, 4, 6, 8}, {0, 1, 1, 0}}.Rat()
+	if got := A.Rank(); got != 2 {
+		t.Fatalf("Rank = %d, want 2", got)
+	}
+	basis := A.NullSpace()
+	if len(basis) != 2 {
+		t.Fatalf("null space has %d vectors, want 2", len(basis))
+	}
+	for _, v := range basis {
+		for i, row := range A {
+			sum := new(big.Rat)
+			for j, a := range row {
+				sum.Add(sum, new(big.Rat).Mul(a, v[j]))
+			}
+			if sum.Sign() != 0 {
+				t.Errorf("row %d · %v = %s, want 0", i, v, sum.RatString())
+			}
+		}
+	}
+}
+
+func TestSolveRat(t *testing.T) {
+	x, err := SolveRat(IntMatrix{{2, 1}, {1, 3}}.Rat(), ratVector(3, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x[0].Cmp(big.NewRat(4, 5)) != 0 || x[1].Cmp(big.NewRat(7, 5)) != 0 {
+		t.Errorf("x = %v, want [4/5 7/5]", x)
+	}
+
+	singular := IntMatrix{{1, 2}, {2, 4}}.Rat()
+	if _, err := SolveRat(singular, ratVector(3, 6)); !errors.Is(err, ErrSingular) {
+		t.Errorf("dependent rows: got %v, want ErrSingular", err)
+	}
+	if _, err := SolveRat(singular, ratVector(3, 7)); !errors.Is(err, ErrInconsistent) {
+		t.Errorf("contradictory rows: got %v, want ErrInconsistent", err)
+	}
+}
+
+func TestSolveInt(t *testing.T) {
+	tests := []struct {
+		name string
+		A    IntMatrix
+		b    []int64
+		err  error
+	}{
+		{"unique", IntMatrix{{94, 22}, {34, 67}}, []int64{8400, 5400}, nil},
+		{"fractional", IntMatrix{{2, 0}, {0, 1}}, []int64{3, 1}, ErrNoIntegerSolution},
+		{"inconsistent", IntMatrix{{1, 1}, {1, 1}}, []int64{1, 2}, ErrInconsistent},
+		// One equation in three unknowns: Smith form picks one of many.
+		{"underdetermined", IntMatrix{{6, 10, 15}}, []int64{7}, nil},
+		{"underdetermined no integer", IntMatrix{{4, 6}}, []int64{5}, ErrNoIntegerSolution},
+		{"overdetermined", IntMatrix{{2, 4}, {4, 8}, {1, 3}}, []int64{10, 20, 8}, nil},
+		{"int64 bounds", IntMatrix{{1, 0}, {0, 1}}, []int64{math.MaxInt64, math.MinInt64}, nil},
+	}
+	for _, tc := range tests {
+		x, err := SolveInt(tc.A, tc.b)
+		if tc.err != nil {
+			if !errors.Is(err, tc.err) {
+				t.Errorf("%s: got %v, want %v", tc.name, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		got, err := tc.A.Apply(x)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.b[i] {
+				t.Errorf("%s: A·%v = %v, want %v", tc.name, x, got, tc.b)
+				break
+			}
+		}
+	}
+}
+
+func TestSolveIntRationalOverflow(t *testing.T) {
+	// The unique rational solution is an integer too big for int64.
+	A := IntMatrix{{1, -1}, {0, 1}}
+	if _, err := SolveInt(A, []int64{math.MaxInt64, 1}); !errors.Is(err, ErrOverflow) {
+		t.Errorf("got %v, want ErrOverflow", err)
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	wide := IntMatrix{{math.MaxInt64 / 2, math.MaxInt64 / 2}}
+	if _, err := wide.Mul(IntMatrix{{3}, {0}}); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Mul: got %v, want ErrOverflow", err)
+	}
+	if _, err := wide.Apply([]int64{1, 1}); err != nil {
+		t.Errorf("Apply within range: %v", err)
+	}
+	if _, err := wide.Apply([]int64{2, 2}); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Apply: got %v, want ErrOverflow", err)
+	}
+	for _, m := range []IntMatrix{
+		{{math.MaxInt64 / 2, 1}, {-2, math.MaxInt64 / 2}},
+		// The subtracted product is MinInt64, which cannot be negated.
+		{{1, math.MinInt64}, {1, 0}},
+		// Singular, but only found so after the first step overflows.
+		{{1 << 40, 0, 0}, {0, 0, 1 << 40}, {0, 0, 1}},
+	} {
+		if d, err := m.Det(); !errors.Is(err, ErrOverflow) {
+			t.Errorf("Det(%v) = %d, %v, want ErrOverflow", m, d, err)
+		}
+	}
+}
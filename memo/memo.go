@@ -0,0 +1,101 @@
+// Package memo provides typed memo tables with usage statistics and an
+// optional LRU bound.
+package memo
+
+import (
+	"container/list"
+	"fmt"
+)
+
+// Stats counts how a Cache has been used.
+type Stats struct {
+	Hits, Misses, Evictions int
+	Size                    int
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("%d hits, %d misses, %d evictions, %d entries", s.Hits, s.Misses, s.Evictions, s.Size)
+}
+
+type cacheEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// Cache is a typed memo table. Each solver makes its own, so nothing
+// carries over between runs. With a limit it evicts the least recently
+// used entry once full.
+type Cache[K comparable, V any] struct {
+	items map[K]*list.Element
+	order *list.List
+	limit int
+	stats Stats
+}
+
+// NewCache returns an unbounded cache.
+func NewCache[K comparable, V any]() *Cache[K, V] {
+	return NewLRU[K, V](0)
+}
+
+// NewLRU returns a cache holding at most limit entries; zero or less
+// means no limit.
+func NewLRU[K comparable, V any](limit int) *Cache[K, V] {
+	return &Cache[K, V]{items: make(map[K]*list.Element), order: list.New(), limit: limit}
+}
+
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	if el, ok := c.items[key]; ok {
+		c.stats.Hits++
+		c.order.MoveToFront(el)
+		return el.Value.(*cacheEntry[K, V]).value, true
+	}
+	c.stats.Misses++
+	var zero V
+	return zero, false
+}
+
+func (c *Cache[K, V]) Put(key K, value V) {
+	if el, ok := c.items[key]; ok {
+		el.Value.(*cacheEntry[K, V]).value = value
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&cacheEntry[K, V]{key, value})
+	if c.limit > 0 && c.order.Len() > c.limit {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry[K, V]).key)
+		c.stats.Evictions++
+	}
+}
+
+func (c *Cache[K, V]) Len() int { return len(c.items) }
+
+func (c *Cache[K, V]) Stats() Stats {
+	s := c.stats
+	s.Size = c.Len()
+	return s
+}
+
+// Reset empties the cache and its statistics.
+func (c *Cache[K, V]) Reset() {
+	c.items = make(map[K]*list.Element)
+	c.order.Init()
+	c.stats = Stats{}
+}
+
+// Recursive memoises a recursive function through c. f receives the
+// memoised function to call for its subproblems. With a bounded cache an
+// evicted result is simply computed again.
+func Recursive[K comparable, V any](c *Cache[K, V], f func(self func(K) V, key K) V) func(K) V {
+	var self func(K) V
+	self = func(key K) V {
+		if v, ok := c.Get(key); ok {
+			return v
+		}
+		v := f(self, key)
+		c.Put(key, v)
+		return v
+	}
+	return self
+}
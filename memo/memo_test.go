@@ -0,0 +1,111 @@
+package memo
+
+import "testing"
+
+func TestStats(t *testing.T) {
+	c := NewCache[string, int]()
+	if _, ok := c.Get("a"); ok {
+		t.Error("empty cache returned a hit")
+	}
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("a", 3) // overwrite, not a new entry
+	if v, ok := c.Get("a"); !ok || v != 3 {
+		t.Errorf("Get(a) = %d, %v, want 3, true", v, ok)
+	}
+	c.Get("b")
+	c.Get("c")
+	want := Stats{Hits: 2, Misses: 2, Evictions: 0, Size: 2}
+	if got := c.Stats(); got != want {
+		t.Errorf("Stats = %+v, want %+v", got, want)
+	}
+
+	c.Reset()
+	if got := c.Stats(); got != (Stats{}) {
+		t.Errorf("Stats after Reset = %+v, want zero", got)
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Error("Reset kept an entry")
+	}
+}
+
+func TestLRUEviction(t *testing.T) {
+	c := NewLRU[int, int](2)
+	c.Put(1, 1)
+	c.Put(2, 2)
+	c.Get(1)    // 1 is now more recent than 2
+	c.Put(3, 3) // evicts 2
+	if _, ok := c.Get(2); ok {
+		t.Error("least recently used entry 2 was kept")
+	}
+	for _, k := range []int{1, 3} {
+		if _, ok := c.Get(k); !ok {
+			t.Errorf("entry %d was evicted", k)
+		}
+	}
+
+	c.Put(1, 10) // refreshes 1, so 3 is now the oldest
+	c.Put(4, 4)
+	if _, ok := c.Get(3); ok {
+		t.Error("Put did not refresh the entry it overwrote")
+	}
+	if v, _ := c.Get(1); v != 10 {
+		t.Errorf("Get(1) = %d, want 10", v)
+	}
+
+	s := c.Stats()
+	if s.Evictions != 2 || s.Size != 2 || c.Len() != 2 {
+		t.Errorf("Stats = %+v, Len = %d, want 2 evictions and 2 entries", s, c.Len())
+	}
+}
+
+func TestUnboundedNeverEvicts(t *testing.T) {
+	c := NewLRU[int, int](0)
+	for i := range 1000 {
+		c.Put(i, i)
+	}
+	if s := c.Stats(); s.Evictions != 0 || s.Size != 1000 {
+		t.Errorf("Stats = %+v, want 1000 entries and no evictions", s)
+	}
+}
+
+func fibonacci(c *Cache[int, int], calls *int) func(int) int {
+	return Recursive(c, func(self func(int) int, n int) int {
+		*calls++
+		if n < 2 {
+			return n
+		}
+		return self(n-1) + self(n-2)
+	})
+}
+
+func TestRecursive(t *testing.T) {
+	calls := 0
+	c := NewCache[int, int]()
+	fib := fibonacci(c, &calls)
+	if got := fib(50); got != 12586269025 {
+		t.Errorf("fib(50) = %d", got)
+	}
+	if calls != 51 {
+		t.Errorf("f ran %d times, want once per n = 51", calls)
+	}
+	fib(50)
+	if calls != 51 {
+		t.Error("a memoised call ran f again")
+	}
+	if s := c.Stats(); s.Size != 51 || s.Misses != 51 {
+		t.Errorf("Stats = %+v, want 51 misses and entries", s)
+	}
+}
+
+func TestRecursiveBounded(t *testing.T) {
+	// Evicted subresults are recomputed, so the answer is still right.
+	calls := 0
+	c := NewLRU[int, int](3)
+	if got := fibonacci(c, &calls)(30); got != 832040 {
+		t.Errorf("fib(30) = %d", got)
+	}
+	if s := c.Stats(); s.Size > 3 {
+		t.Errorf("bounded cache grew to %d entries", s.Size)
+	}
+}
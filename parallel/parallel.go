@@ -0,0 +1,112 @@
+// Package parallel runs independent work items across a bounded pool of
+// goroutines, keeping results in input order.
+package parallel
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Options tunes a parallel run. The zero value uses one worker per CPU.
+type Options struct {
+	// Workers bounds the goroutines used; zero or less means one per CPU.
+	Workers int
+}
+
+func (o Options) workerCount(n int) int {
+	w := o.Workers
+	if w <= 0 {
+		w = runtime.NumCPU()
+	}
+	return max(1, min(w, n))
+}
+
+// forEach calls fn(i) for every i in [0, n) across the worker pool,
+// stopping early once ctx is done.
+func forEach(ctx context.Context, opts Options, n int, fn func(i int)) error {
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range opts.workerCount(n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				i := int(next.Add(1) - 1)
+				if i >= n {
+					return
+				}
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// Map applies fn to every item in parallel; out[i] is always fn(items[i]).
+func Map[T, R any](ctx context.Context, opts Options, items []T, fn func(T) R) ([]R, error) {
+	out := make([]R, len(items))
+	err := forEach(ctx, opts, len(items), func(i int) { out[i] = fn(items[i]) })
+	return out, err
+}
+
+// Reduce folds contiguous chunks of items in parallel, each starting from
+// a fresh init(), then merges the chunk results left to right. With an
+// associative merge the result matches a serial fold.
+func Reduce[T, A any](ctx context.Context, opts Options, items []T, init func() A, fold func(A, T) A, merge func(A, A) A) (A, error) {
+	chunks := opts.workerCount(len(items)) * 4
+	size := (len(items) + chunks - 1) / max(chunks, 1)
+	if size == 0 {
+		return init(), ctx.Err()
+	}
+	chunks = (len(items) + size - 1) / size
+
+	partial := make([]A, chunks)
+	err := forEach(ctx, opts, chunks, func(c int) {
+		acc := init()
+		for _, item := range items[c*size : min((c+1)*size, len(items))] {
+			acc = fold(acc, item)
+		}
+		partial[c] = acc
+	})
+	if err != nil {
+		return init(), err
+	}
+
+	result := partial[0]
+	for _, p := range partial[1:] {
+		result = merge(result, p)
+	}
+	return result, nil
+}
+
+// Count returns how many items satisfy pred.
+func Count[T any](ctx context.Context, opts Options, items []T, pred func(T) bool) (int, error) {
+	return Reduce(ctx, opts, items,
+		func() int { return 0 },
+		func(n int, item T) int {
+			if pred(item) {
+				n++
+			}
+			return n
+		},
+		func(a, b int) int { return a + b })
+}
+
+// Cell is one grid coordinate, for running Map/Count over a grid.
+type Cell struct {
+	Row, Col int
+}
+
+// Cells lists every cell of a rows×cols grid in row-major order.
+func Cells(rows, cols int) []Cell {
+	out := make([]Cell, 0, rows*cols)
+	for r := range rows {
+		for c := range cols {
+			out = append(out, Cell{r, c})
+		}
+	}
+	return out
+}
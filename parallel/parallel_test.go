@@ -0,0 +1,130 @@
+package parallel
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func numbers(n int) []int {
+	out := make([]int, n)
+	for i := range out {
+		out[i] = i
+	}
+	return out
+}
+
+func TestMapOrder(t *testing.T) {
+	items := numbers(1000)
+	for _, workers := range []int{0, 1, 3, 64} {
+		out, err := Map(context.Background(), Options{Workers: workers}, items, func(i int) string {
+			// Finish out of order so any reordering would show.
+			if i%7 == 0 {
+				time.Sleep(time.Microsecond)
+			}
+			return strconv.Itoa(i * i)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range out {
+			if v != strconv.Itoa(i*i) {
+				t.Fatalf("workers=%d: out[%d] = %s, want %d", workers, i, v, i*i)
+			}
+		}
+	}
+}
+
+func TestReduceMergeOrder(t *testing.T) {
+	// String concatenation is associative but not commutative, so the
+	// result only matches a serial fold if chunks merge left to right.
+	items := numbers(257)
+	want := ""
+	for _, i := range items {
+		want += strconv.Itoa(i) + ","
+	}
+	for _, workers := range []int{1, 2, 5, 16} {
+		got, err := Reduce(context.Background(), Options{Workers: workers}, items,
+			func() string { return "" },
+			func(acc string, i int) string { return acc + strconv.Itoa(i) + "," },
+			func(a, b string) string { return a + b })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("workers=%d: Reduce gave %q, want the serial fold", workers, got)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	got, err := Count(context.Background(), Options{Workers: 4}, numbers(100), func(i int) bool { return i%3 == 0 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 34 {
+		t.Errorf("Count = %d, want 34", got)
+	}
+}
+
+func TestEmptyInput(t *testing.T) {
+	ctx := context.Background()
+	out, err := Map(ctx, Options{}, []int(nil), func(i int) int { return i })
+	if err != nil || len(out) != 0 {
+		t.Errorf("Map on nothing = %v, %v", out, err)
+	}
+	sum, err := Reduce(ctx, Options{}, []int{},
+		func() int { return 42 },
+		func(a, i int) int { return a + i },
+		func(a, b int) int { return a + b })
+	if err != nil || sum != 42 {
+		t.Errorf("Reduce on nothing = %d, %v, want init() = 42", sum, err)
+	}
+	n, err := Count(ctx, Options{}, []int{}, func(int) bool { return true })
+	if err != nil || n != 0 {
+		t.Errorf("Count on nothing = %d, %v", n, err)
+	}
+}
+
+func TestCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls atomic.Int64
+	_, err := Map(ctx, Options{Workers: 2}, numbers(10000), func(i int) int {
+		if calls.Add(1) == 10 {
+			cancel()
+		}
+		return i
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Map error = %v, want context.Canceled", err)
+	}
+	if n := calls.Load(); n >= 10000 {
+		t.Errorf("Map kept going after cancel: %d calls", n)
+	}
+
+	done, cancel := context.WithCancel(context.Background())
+	cancel()
+	sum, err := Reduce(done, Options{}, numbers(100),
+		func() int { return 0 },
+		func(a, i int) int { return a + i },
+		func(a, b int) int { return a + b })
+	if !errors.Is(err, context.Canceled) || sum != 0 {
+		t.Errorf("Reduce on a cancelled context = %d, %v", sum, err)
+	}
+}
+
+func TestCells(t *testing.T) {
+	cells := Cells(2, 3)
+	want := []Cell{{0, 0}, {0, 1}, {0, 2}, {1, 0}, {1, 1}, {1, 2}}
+	if len(cells) != len(want) {
+		t.Fatalf("Cells(2, 3) = %v", cells)
+	}
+	for i := range want {
+		if cells[i] != want[i] {
+			t.Fatalf("Cells(2, 3) = %v, want %v", cells, want)
+		}
+	}
+}